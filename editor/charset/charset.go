@@ -0,0 +1,267 @@
+package charset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/korean"
+)
+
+// Encoding: 파일의 텍스트 인코딩
+// 문서 내부(PieceTable)는 항상 rune 기반이고, 인코딩은 읽고 쓸 때만 사용됨
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	UTF8BOM
+	UTF16LE // BOM 포함
+	UTF16BE // BOM 포함
+	CP949   // 확장 완성형 (UHC)
+	EUCKR   // KS X 1001 완성형만
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+var encodingNames = map[Encoding]string{
+	UTF8:    "utf-8",
+	UTF8BOM: "utf-8-bom",
+	UTF16LE: "utf-16le",
+	UTF16BE: "utf-16be",
+	CP949:   "cp949",
+	EUCKR:   "euc-kr",
+}
+
+// 사용자가 입력할 만한 별칭들
+var encodingAliases = map[string]Encoding{
+	"utf8":           UTF8,
+	"utf-8":          UTF8,
+	"utf8bom":        UTF8BOM,
+	"utf-8-bom":      UTF8BOM,
+	"utf16":          UTF16LE,
+	"utf-16":         UTF16LE,
+	"utf16le":        UTF16LE,
+	"utf-16le":       UTF16LE,
+	"utf16be":        UTF16BE,
+	"utf-16be":       UTF16BE,
+	"cp949":          CP949,
+	"ms949":          CP949,
+	"uhc":            CP949,
+	"euckr":          EUCKR,
+	"euc-kr":         EUCKR,
+	"ks_c_5601":      EUCKR,
+	"ksc5601":        EUCKR,
+	"ks_c_5601-1987": EUCKR,
+}
+
+func (e Encoding) String() string {
+	if name, ok := encodingNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("encoding(%d)", int(e))
+}
+
+// Parse: 이름(대소문자 무시)으로 인코딩 찾기
+func Parse(name string) (Encoding, error) {
+	if enc, ok := encodingAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return enc, nil
+	}
+	return UTF8, fmt.Errorf("알 수 없는 인코딩: %q", name)
+}
+
+// Names: 지원하는 인코딩 이름 목록 (자동완성용)
+func Names() []string {
+	return []string{"utf-8", "utf-8-bom", "utf-16le", "utf-16be", "cp949", "euc-kr"}
+}
+
+// ErrUnrepresentable: 저장할 인코딩으로 표현할 수 없는 문자가 있음
+var ErrUnrepresentable = errors.New("charset: 표현할 수 없는 문자가 있습니다")
+
+// Detect: BOM을 먼저 보고, 없으면 내용으로 추측
+func Detect(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE
+	}
+	// ASCII 범위의 UTF-16은 NUL이 섞인 유효한 UTF-8이기도 해서 UTF-8보다 먼저 봄
+	if enc, ok := detectUTF16(data); ok {
+		return enc
+	}
+	if utf8.Valid(data) {
+		return UTF8
+	}
+	if enc, ok := detectKorean(data); ok {
+		return enc
+	}
+	// 알 수 없으면 UTF-8로 보고 깨진 바이트는 U+FFFD로 읽음
+	return UTF8
+}
+
+// detectUTF16: BOM 없는 UTF-16 추측
+// 영문 위주 텍스트는 한쪽 바이트가 거의 0이라는 점을 이용
+func detectUTF16(data []byte) (Encoding, bool) {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return UTF8, false
+	}
+	var evenZero, oddZero int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZero++
+		}
+		if data[i+1] == 0 {
+			oddZero++
+		}
+	}
+	units := len(data) / 2
+	switch {
+	case oddZero*10 >= units*4 && evenZero*10 < units:
+		return UTF16LE, true
+	case evenZero*10 >= units*4 && oddZero*10 < units:
+		return UTF16BE, true
+	}
+	return UTF8, false
+}
+
+// detectKorean: 2바이트 조합이 CP949/EUC-KR 범위 안에 있는지 확인
+// 모든 2바이트 문자가 KS X 1001 영역(A1-FE)이면 EUC-KR, 확장 영역이 섞였으면 CP949
+func detectKorean(data []byte) (Encoding, bool) {
+	extended := false
+	for i := 0; i < len(data); i++ {
+		lead := data[i]
+		if lead < 0x80 {
+			continue
+		}
+		if i+1 >= len(data) {
+			return UTF8, false
+		}
+		trail := data[i+1]
+		switch {
+		case lead >= 0xA1 && lead <= 0xFE && trail >= 0xA1 && trail <= 0xFE:
+			// KS X 1001
+		case lead >= 0x81 && lead <= 0xFE && isCP949Trail(trail):
+			extended = true
+		default:
+			return UTF8, false
+		}
+		i++
+	}
+	if extended {
+		return CP949, true
+	}
+	return EUCKR, true
+}
+
+func isCP949Trail(b byte) bool {
+	return (b >= 0x41 && b <= 0x5A) || (b >= 0x61 && b <= 0x7A) || (b >= 0x81 && b <= 0xFE)
+}
+
+// DecodeBytes: Decode와 같지만 UTF-8 바이트로 돌려줌
+// UTF-8(BOM 포함)이면 복사하지 않고 data의 일부를 그대로 돌려주므로 읽기 전용 매핑도 그대로 씀
+func DecodeBytes(data []byte, enc Encoding) ([]byte, error) {
+	switch enc {
+	case UTF8:
+		return data, nil
+	case UTF8BOM:
+		return bytes.TrimPrefix(data, bomUTF8), nil
+	}
+	text, err := Decode(data, enc)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// Decode: data를 enc로 해석해서 문자열로 (BOM은 제거)
+func Decode(data []byte, enc Encoding) (string, error) {
+	switch enc {
+	case UTF8:
+		return string(data), nil
+	case UTF8BOM:
+		return string(bytes.TrimPrefix(data, bomUTF8)), nil
+	case UTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), binary.LittleEndian)
+	case UTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), binary.BigEndian)
+	case CP949, EUCKR:
+		// x/text의 EUCKR 디코더는 CP949 확장 영역까지 처리함
+		out, err := korean.EUCKR.NewDecoder().Bytes(data)
+		if err != nil {
+			return "", fmt.Errorf("%s 디코딩 실패: %w", enc, err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("지원하지 않는 인코딩: %s", enc)
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("UTF-16 데이터 길이가 홀수입니다 (%d 바이트)", len(data))
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// Encode: text를 enc 바이트로 (UTF-8 BOM, UTF-16은 BOM 포함)
+func Encode(text string, enc Encoding) ([]byte, error) {
+	switch enc {
+	case UTF8:
+		return []byte(text), nil
+	case UTF8BOM:
+		return append(append([]byte{}, bomUTF8...), text...), nil
+	case UTF16LE:
+		return encodeUTF16(text, bomUTF16LE, binary.LittleEndian), nil
+	case UTF16BE:
+		return encodeUTF16(text, bomUTF16BE, binary.BigEndian), nil
+	case CP949, EUCKR:
+		out, err := korean.EUCKR.NewEncoder().Bytes([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s (%w)", ErrUnrepresentable, enc, err)
+		}
+		if enc == EUCKR {
+			if err := checkEUCKR(out); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("지원하지 않는 인코딩: %s", enc)
+}
+
+func encodeUTF16(text string, bom []byte, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(text))
+	out := make([]byte, len(bom)+len(units)*2)
+	copy(out, bom)
+	for i, u := range units {
+		order.PutUint16(out[len(bom)+i*2:], u)
+	}
+	return out
+}
+
+// checkEUCKR: CP949 확장 영역(KS X 1001 밖)의 문자가 섞였는지 확인
+func checkEUCKR(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		if data[i] < 0x80 {
+			continue
+		}
+		if data[i] < 0xA1 || i+1 >= len(data) || data[i+1] < 0xA1 {
+			return fmt.Errorf("%w: EUC-KR (%d 바이트 위치, CP949로 저장하세요)", ErrUnrepresentable, i)
+		}
+		i++
+	}
+	return nil
+}
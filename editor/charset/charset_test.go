@@ -0,0 +1,72 @@
+package charset
+
+import "testing"
+
+func TestDetectAndDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		enc  Encoding
+		text string
+	}{
+		{"utf-8", []byte("hello 한글"), UTF8, "hello 한글"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFhi"), UTF8BOM, "hi"},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'h', 0, 0x5C, 0xD5}, UTF16LE, "h한"},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'h', 0xD5, 0x5C}, UTF16BE, "h한"},
+		{"euc-kr", []byte{'a', 0xC7, 0xD1, 0xB1, 0xDB}, EUCKR, "a한글"},
+		{"cp949", []byte{0x8C, 0x63, 0xC7, 0xD1}, CP949, "똠한"},
+	}
+	for _, c := range cases {
+		enc := Detect(c.data)
+		if enc != c.enc {
+			t.Errorf("%s: Detect = %s, want %s", c.name, enc, c.enc)
+			continue
+		}
+		text, err := Decode(c.data, enc)
+		if err != nil || text != c.text {
+			t.Errorf("%s: Decode = %q, %v, want %q", c.name, text, err, c.text)
+			continue
+		}
+		// 원래 인코딩으로 다시 저장하면 같은 바이트가 나와야 함
+		out, err := Encode(text, enc)
+		if err != nil || string(out) != string(c.data) {
+			t.Errorf("%s: Encode = % X, %v, want % X", c.name, out, err, c.data)
+		}
+	}
+}
+
+func TestEncodeEUCKRRejectsExtended(t *testing.T) {
+	// '똠'은 CP949 확장 영역이라 EUC-KR로는 저장 불가
+	if _, err := Encode("똠", EUCKR); err == nil {
+		t.Fatal("EUC-KR 인코딩이 확장 영역 문자를 허용함")
+	}
+	if _, err := Encode("똠", CP949); err != nil {
+		t.Fatalf("CP949 인코딩 실패: %v", err)
+	}
+}
+
+// BOM 없는 UTF-16: ASCII 범위면 NUL이 섞인 유효한 UTF-8이기도 함
+func TestDetectUTF16WithoutBOM(t *testing.T) {
+	le := []byte{'l', 0, 'o', 0, 'g', 0, '\n', 0, 0x5C, 0xD5}
+	be := []byte{0, 'l', 0, 'o', 0, 'g', 0, '\n', 0xD5, 0x5C}
+	for _, c := range []struct {
+		name string
+		data []byte
+		enc  Encoding
+	}{
+		{"utf-16le", le, UTF16LE},
+		{"utf-16be", be, UTF16BE},
+	} {
+		if enc := Detect(c.data); enc != c.enc {
+			t.Errorf("%s: Detect = %s, want %s", c.name, enc, c.enc)
+			continue
+		}
+		if text, err := Decode(c.data, c.enc); err != nil || text != "log\n한" {
+			t.Errorf("%s: Decode = %q, %v", c.name, text, err)
+		}
+	}
+	// 짝수 길이의 평범한 ASCII는 여전히 UTF-8
+	if enc := Detect([]byte("plain text")); enc != UTF8 {
+		t.Errorf("ASCII: Detect = %s", enc)
+	}
+}
@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"go_editor/editor/charset"
+	"go_editor/editor/commander"
+	"go_editor/editor/syncer"
+	"strconv"
+)
+
+// set 명령으로 바꿀 수 있는 설정 이름
+var settingNames = []string{"tabwidth", "linenumbers", "zoom"}
+
+// handleMinibuffer: 명령줄이 열려 있을 때의 키 입력
+// Enter면 입력한 명령을 Command로 바꿔서 일반 명령과 같은 경로로 실행
+func (e *Editor) handleMinibuffer(cmd commander.Command) {
+	if e.minibuffer.HandleCommand(cmd) != commander.MinibufferSubmit {
+		return
+	}
+	cmds, err := commander.ParseEx(e.minibuffer.Text())
+	if err != nil {
+		e.syncProtocol.SetMessage("%s", syncer.StatusError(err))
+		return
+	}
+	for _, c := range cmds {
+		e.processCommand(c)
+		if !e.running {
+			return
+		}
+	}
+}
+
+// completeArgument: 명령줄 인자 자동완성 후보
+func (e *Editor) completeArgument(command, arg string) []string {
+	switch command {
+	case "theme":
+		return e.themes.Names()
+	case "encoding", "saveas-encoding":
+		return charset.Names()
+	case "set":
+		names := make([]string, len(settingNames))
+		for i, name := range settingNames {
+			names[i] = name + "="
+		}
+		return names
+	}
+	return nil
+}
+
+// applySetting: set name=value (실행중에만 바뀌고 설정 파일에는 쓰지 않음)
+func (e *Editor) applySetting(name, value string) {
+	switch name {
+	case "tabwidth":
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 1 || width > 16 {
+			e.syncProtocol.SetMessage("tabwidth must be 1-16")
+			return
+		}
+		e.syncProtocol.SetTabWidth(width)
+	case "linenumbers":
+		switch value {
+		case "off", "absolute", "relative":
+			e.syncProtocol.SetGutterMode(gutterMode(value))
+		default:
+			e.syncProtocol.SetMessage("linenumbers must be off, absolute or relative")
+			return
+		}
+	case "zoom":
+		scale, err := strconv.Atoi(value)
+		if err != nil || scale < syncer.MinScale || scale > syncer.MaxScale {
+			e.syncProtocol.SetMessage("zoom must be %d-%d", syncer.MinScale, syncer.MaxScale)
+			return
+		}
+		e.setZoom(scale)
+		return
+	default:
+		e.syncProtocol.SetMessage("Unknown setting: %s", name)
+		return
+	}
+	e.logger.Info("설정 변경", "name", name, "value", value)
+	e.syncProtocol.SetMessage("%s=%s", name, value)
+}
@@ -2,6 +2,9 @@ package commander
 
 import (
 	"fmt"
+	"go_editor/editor/logging"
+	"log/slog"
+	"sync"
 	"unicode"
 
 	"github.com/BurntSushi/xgb"
@@ -14,12 +17,27 @@ import (
 type Commander struct {
 	xu        *xgbutil.XUtil
 	eventChan chan Command
+
+	// 키 바인딩 (설정 리로드시 메인 루프에서 교체되므로 잠금)
+	keymapMu sync.RWMutex
+	keymap   Keymap
+
+	logger *slog.Logger
 }
 type Command struct {
 	Code  CommandCode
 	Input CommandInput
 }
 
+// IsTyping: 줄바꿈이 아닌 글자 입력인지 (연속 입력을 묶어서 처리할 때 사용)
+func (c Command) IsTyping() bool {
+	if c.Code != CmdInsert {
+		return false
+	}
+	ch, ok := c.Input.(CharInput)
+	return ok && ch.Char != KeyEnter1 && ch.Char != KeyEnter2
+}
+
 // CommandCode: 명령 코드
 type CommandCode uint8
 
@@ -29,6 +47,27 @@ const (
 	CmdInsert
 	CmdDelete
 	CmdExit
+	CmdReopenWithEncoding
+	CmdSaveWithEncoding
+	CmdSave
+	CmdNextTheme
+	CmdSetTheme
+	CmdZoomIn
+	CmdZoomOut
+	CmdZoomReset
+	CmdToggleLineNumbers
+	CmdClick
+	CmdCommandLine
+	CmdGoto
+	CmdOpen
+	CmdSet
+	CmdSubstitute
+	CmdFind
+	CmdFindNext
+	CmdFindPrev
+	CmdUndo
+	CmdRedo
+	CmdSetMark
 )
 
 // CommandInput 인터페이스
@@ -43,13 +82,69 @@ type CharInput struct {
 
 func (c CharInput) IsCommandInput() {}
 
-// ClickInput: 마우스 클릭 입력
+// ClickInput: 마우스 클릭 입력 (창 기준 픽셀 좌표, Height=y, Width=x)
 type ClickInput struct {
 	Height, Width int
 }
 
 func (c ClickInput) IsCommandInput() {}
 
+// EncodingInput: 인코딩 이름 입력 (다른 인코딩으로 다시 열기/저장)
+type EncodingInput struct {
+	Name string
+}
+
+func (e EncodingInput) IsCommandInput() {}
+
+// ThemeInput: 테마 이름 입력
+type ThemeInput struct {
+	Name string
+}
+
+func (t ThemeInput) IsCommandInput() {}
+
+// LineInput: 1부터 시작하는 라인 번호 (goto)
+type LineInput struct {
+	Line int
+}
+
+func (l LineInput) IsCommandInput() {}
+
+// PathInput: 파일 경로 (e path)
+type PathInput struct {
+	Path string
+}
+
+func (p PathInput) IsCommandInput() {}
+
+// SettingInput: set name=value
+type SettingInput struct {
+	Name, Value string
+}
+
+func (s SettingInput) IsCommandInput() {}
+
+// SubstituteInput: [range]s/pattern/replacement/flags
+type SubstituteInput struct {
+	Pattern     string // Go regexp 문법 (^, $는 라인 단위, \n으로 라인 경계를 넘는 매치 가능)
+	Replacement string // $1, ${name} 캡처 그룹 사용 가능, \n은 줄바꿈
+	Global      bool   // g: 라인의 모든 매치
+	Confirm     bool   // c: 매치마다 확인
+	IgnoreCase  bool   // i: 대소문자 무시
+	Range       SubstituteRange
+}
+
+// SubstituteRange: 치환할 범위
+type SubstituteRange int
+
+const (
+	RangeLine      SubstituteRange = iota // 커서 라인 (s)
+	RangeDocument                         // 문서 전체 (%s)
+	RangeSelection                        // 선택 영역 ('<,'>s)
+)
+
+func (s SubstituteInput) IsCommandInput() {}
+
 // X11 KeySym 상수 정의 (X11/keysymdef.h 참고)
 const (
 	XK_ESC       = 0xFF1B
@@ -61,6 +156,9 @@ const (
 	XK_Right     = 0xFF53
 	XK_Up        = 0xFF52
 	XK_Down      = 0xFF54
+	XK_Tab       = 0xFF09
+	XK_F1        = 0xFFBE
+	XK_F12       = 0xFFC9
 )
 
 const (
@@ -72,29 +170,82 @@ const (
 	KeyDown      rune = 0xFF54
 	KeyEnter1    rune = '\n'
 	KeyEnter2    rune = 0xFF0D
+	KeyTab       rune = '\t'
+	KeyF1        rune = 0xFFBE // F1~F12는 연속된 값
 )
 
+// 이 값 이상의 rune은 글자가 아니라 특수키
+const specialKeyBase rune = 0xFF00
+
 // Command: 실행할 명령
 func NewCommandor(xu *xgbutil.XUtil) *Commander {
+	keymap, _ := ParseKeymap(DefaultBindings())
 	return &Commander{
 		xu:        xu,
 		eventChan: make(chan Command, 20),
+		keymap:    keymap,
+		logger:    logging.Discard(),
 	}
 }
 
+// SetLogger: 로거 교체 (StartListening 전에 호출)
+func (c *Commander) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Discard()
+	}
+	c.logger = l
+}
+
+// SetKeymap: 키 바인딩 교체
+func (c *Commander) SetKeymap(km Keymap) {
+	c.keymapMu.Lock()
+	c.keymap = km
+	c.keymapMu.Unlock()
+}
+
+// modifiersFromState: X 이벤트 state -> Modifier
+func modifiersFromState(state uint16) Modifier {
+	var mods Modifier
+	if state&xproto.ModMaskShift > 0 {
+		mods |= ModShift
+	}
+	if state&xproto.ModMaskControl > 0 {
+		mods |= ModCtrl
+	}
+	if state&xproto.ModMask1 > 0 {
+		mods |= ModAlt
+	}
+	return mods
+}
+
 // TranslateXEventToCommand: X 이벤트 -> Command 변환
 func (c *Commander) TranslateXEventToCommand(ev xgb.Event) (Command, bool) {
 	switch e := ev.(type) {
 	case xproto.KeyPressEvent:
 		keyRune, err := TranslateKeyCode(c.xu, e.Detail, e.State)
 		if err != nil {
+			c.logger.Debug("키 변환 실패", "keycode", e.Detail, "err", err)
+			return Command{}, false
+		}
+
+		// 바인딩된 키 조합이 먼저
+		mods := modifiersFromState(e.State)
+		c.keymapMu.RLock()
+		bound, ok := c.keymap.commandForChord(Chord{Mods: mods, Key: keyRune})
+		c.keymapMu.RUnlock()
+		if ok {
+			return bound, true
+		}
+		// Ctrl/Alt 조합인데 바인딩이 없으면 무시 (글자로 입력되지 않게)
+		if mods&(ModCtrl|ModAlt) != 0 {
 			return Command{}, false
 		}
 
 		var cmd CommandCode
 		switch keyRune {
 		case KeyESC:
-			cmd = CmdExit
+			// 바인딩이 없는 ESC는 무시
+			return Command{}, false
 		case KeyBackSpace:
 			cmd = CmdDelete
 		case KeyLeft, KeyRight, KeyDown, KeyUp:
@@ -102,6 +253,10 @@ func (c *Commander) TranslateXEventToCommand(ev xgb.Event) (Command, bool) {
 		case KeyEnter1, KeyEnter2:
 			cmd = CmdInsert
 		default:
+			if keyRune >= specialKeyBase {
+				// 바인딩 없는 특수키(F1 등)는 글자로 넣지 않음
+				return Command{}, false
+			}
 			cmd = CmdInsert
 		}
 
@@ -109,6 +264,15 @@ func (c *Commander) TranslateXEventToCommand(ev xgb.Event) (Command, bool) {
 			Code:  cmd,
 			Input: CharInput{keyRune},
 		}, true
+	case xproto.ButtonPressEvent:
+		// 왼쪽 버튼만 커서 이동으로 씀
+		if e.Detail != xproto.ButtonIndex1 {
+			return Command{}, false
+		}
+		return Command{
+			Code:  CmdClick,
+			Input: ClickInput{Height: int(e.EventY), Width: int(e.EventX)},
+		}, true
 	default:
 		return Command{}, false
 	}
@@ -119,6 +283,7 @@ func (c *Commander) collectCommands() {
 	for {
 		ev, err := c.xu.Conn().WaitForEvent()
 		if err != nil {
+			c.logger.Info("X 연결 종료, 입력 수집 중단", "err", err)
 			close(c.eventChan)
 			return
 		}
@@ -166,6 +331,11 @@ func TranslateKeyCode(xu *xgbutil.XUtil, keycode xproto.Keycode, state uint16) (
 		return KeyUp, nil
 	case XK_Down:
 		return KeyDown, nil
+	case XK_Tab:
+		return KeyTab, nil
+	}
+	if keysym >= XK_F1 && keysym <= XK_F12 {
+		return KeyF1 + rune(keysym-XK_F1), nil
 	}
 
 	// 일반 문자 키 처리
@@ -0,0 +1,160 @@
+package commander
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exCommand: 명령줄에서 쓸 수 있는 명령 하나
+type exCommand struct {
+	usage string
+	parse func(arg string) ([]Command, error)
+}
+
+// 명령줄 명령 이름 -> 해석기
+// 결과는 키 입력과 같은 Command라서 같은 처리 경로(Editor -> SyncProtocol)를 탐
+var exCommands = map[string]exCommand{
+	"w": {"w", noArg(Command{Code: CmdSave})},
+	"q": {"q", noArg(Command{Code: CmdExit})},
+	"wq": {"wq", noArg(
+		Command{Code: CmdSave},
+		Command{Code: CmdExit},
+	)},
+	"e": {"e <path>", func(arg string) ([]Command, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("usage: e <path>")
+		}
+		return []Command{{Code: CmdOpen, Input: PathInput{Path: arg}}}, nil
+	}},
+	"goto": {"goto <line>", func(arg string) ([]Command, error) {
+		line, err := strconv.Atoi(arg)
+		if err != nil || line < 1 {
+			return nil, fmt.Errorf("usage: goto <line>")
+		}
+		return []Command{{Code: CmdGoto, Input: LineInput{Line: line}}}, nil
+	}},
+	"set": {"set <name>=<value>", func(arg string) ([]Command, error) {
+		name, value, ok := strings.Cut(arg, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("usage: set <name>=<value>")
+		}
+		return []Command{{Code: CmdSet, Input: SettingInput{Name: name, Value: value}}}, nil
+	}},
+	"theme": {"theme <name>", func(arg string) ([]Command, error) {
+		if arg == "" {
+			return []Command{{Code: CmdNextTheme}}, nil
+		}
+		return []Command{{Code: CmdSetTheme, Input: ThemeInput{Name: arg}}}, nil
+	}},
+	"encoding":        {"encoding <name>", encodingArg(CmdReopenWithEncoding)},
+	"saveas-encoding": {"saveas-encoding <name>", encodingArg(CmdSaveWithEncoding)},
+}
+
+func noArg(cmds ...Command) func(string) ([]Command, error) {
+	return func(arg string) ([]Command, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+		return cmds, nil
+	}
+}
+
+func encodingArg(code CommandCode) func(string) ([]Command, error) {
+	return func(arg string) ([]Command, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("missing encoding name")
+		}
+		return []Command{{Code: code, Input: EncodingInput{Name: arg}}}, nil
+	}
+}
+
+// ExCommandNames: 명령줄 명령 이름 목록 (자동완성용)
+func ExCommandNames() []string {
+	names := make([]string, 0, len(exCommands)+1)
+	for name := range exCommands {
+		names = append(names, name)
+	}
+	names = append(names, "s")
+	sort.Strings(names)
+	return names
+}
+
+// ParseEx: 명령줄 한 줄 -> 실행할 Command들
+// 에러 메시지는 상태 표시줄에 그대로 보이므로 영어로 씀
+func ParseEx(line string) ([]Command, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	// 치환 범위: %는 문서 전체, '<,'>는 선택 영역 (vim과 같음)
+	rng := RangeLine
+	switch {
+	case strings.HasPrefix(line, "%s"):
+		rng, line = RangeDocument, line[1:]
+	case strings.HasPrefix(line, SelectionRangePrefix+"s"):
+		rng, line = RangeSelection, line[len(SelectionRangePrefix):]
+	}
+	if strings.HasPrefix(line, "s") && len(line) > 1 && !isWordByte(line[1]) {
+		// s/foo/bar/g 는 구분자가 이름에 붙어 있음
+		return parseSubstitute(line[1:], rng)
+	}
+	name, arg, _ := strings.Cut(line, " ")
+	// goto는 숫자만 써도 됨 (:120)
+	if _, err := strconv.Atoi(name); err == nil && arg == "" {
+		name, arg = "goto", name
+	}
+	ex, ok := exCommands[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %s", name)
+	}
+	return ex.parse(strings.TrimSpace(arg))
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// SelectionRangePrefix: 선택 영역이 있을 때 명령줄을 열면 미리 채워두는 범위
+const SelectionRangePrefix = "'<,'>"
+
+// parseSubstitute: "/pattern/replacement/flags" (첫 글자가 구분자, \로 구분자 이스케이프)
+func parseSubstitute(s string, rng SubstituteRange) ([]Command, error) {
+	delim := s[0]
+	var fields []string
+	var cur strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			cur.WriteByte(delim)
+			i++
+		case s[i] == delim:
+			fields = append(fields, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	fields = append(fields, cur.String())
+	if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+		return nil, fmt.Errorf("usage: [%%]s/pattern/replacement/[gci]")
+	}
+	input := SubstituteInput{Pattern: fields[0], Replacement: fields[1], Range: rng}
+	if len(fields) == 3 {
+		for _, flag := range fields[2] {
+			switch flag {
+			case 'g':
+				input.Global = true
+			case 'c':
+				input.Confirm = true
+			case 'i':
+				input.IgnoreCase = true
+			default:
+				return nil, fmt.Errorf("unknown substitute flag %q", flag)
+			}
+		}
+	}
+	return []Command{{Code: CmdSubstitute, Input: input}}, nil
+}
@@ -0,0 +1,198 @@
+package commander
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modifier: 키 조합의 수식키
+type Modifier uint8
+
+const (
+	ModShift Modifier = 1 << iota
+	ModCtrl
+	ModAlt
+)
+
+// Chord: 수식키 + 키 하나 (예: Ctrl+S, Shift+F3)
+// 글자 키는 소문자로 정규화하고, 기호 키는 Shift가 이미 기호에 반영되어 있으므로 Shift를 뺀다.
+type Chord struct {
+	Mods Modifier
+	Key  rune
+}
+
+// Keymap: 키 조합 -> 액션 이름
+type Keymap map[Chord]string
+
+// 액션 이름 -> 실행할 명령
+var actionCommands = map[string]CommandCode{
+	"quit":       CmdExit,
+	"save":       CmdSave,
+	"next-theme": CmdNextTheme,
+	"zoom-in":    CmdZoomIn,
+	"zoom-out":   CmdZoomOut,
+	"zoom-reset": CmdZoomReset,
+
+	"toggle-line-numbers": CmdToggleLineNumbers,
+	"command-line":        CmdCommandLine,
+	"find":                CmdFind,
+	"find-next":           CmdFindNext,
+	"find-prev":           CmdFindPrev,
+	"undo":                CmdUndo,
+	"redo":                CmdRedo,
+	"set-mark":            CmdSetMark,
+}
+
+// 이름 있는 키들
+var namedKeys = map[string]rune{
+	"escape":    KeyESC,
+	"esc":       KeyESC,
+	"enter":     KeyEnter1,
+	"return":    KeyEnter1,
+	"backspace": KeyBackSpace,
+	"tab":       KeyTab,
+	"left":      KeyLeft,
+	"right":     KeyRight,
+	"up":        KeyUp,
+	"down":      KeyDown,
+	"space":     ' ',
+}
+
+// DefaultBindings: 설정 파일이 없을 때의 키 바인딩
+func DefaultBindings() map[string]string {
+	return map[string]string{
+		"Escape":       "quit",
+		"Ctrl+S":       "save",
+		"Ctrl+T":       "next-theme",
+		"Ctrl+=":       "zoom-in",
+		"Ctrl++":       "zoom-in",
+		"Ctrl+-":       "zoom-out",
+		"Ctrl+0":       "zoom-reset",
+		"Ctrl+L":       "toggle-line-numbers",
+		"Ctrl+P":       "command-line",
+		"Ctrl+F":       "find",
+		"F3":           "find-next",
+		"Shift+F3":     "find-prev",
+		"Ctrl+Z":       "undo",
+		"Ctrl+Y":       "redo",
+		"Ctrl+Shift+Z": "redo",
+		"Ctrl+Space":   "set-mark",
+	}
+}
+
+// ActionNames: 바인딩 가능한 액션 이름 목록
+func ActionNames() []string {
+	names := make([]string, 0, len(actionCommands))
+	for name := range actionCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseKeymap: "Ctrl+S": "save" 형태의 바인딩을 Keymap으로 변환
+func ParseKeymap(bindings map[string]string) (Keymap, error) {
+	km := Keymap{}
+	var errs []string
+	for chordStr, action := range bindings {
+		chord, err := ParseChord(chordStr)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if _, ok := actionCommands[action]; !ok {
+			errs = append(errs, fmt.Sprintf("%q: 알 수 없는 액션 %q (가능: %s)",
+				chordStr, action, strings.Join(ActionNames(), ", ")))
+			continue
+		}
+		km[chord] = action
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("키 바인딩 오류: %s", strings.Join(errs, "; "))
+	}
+	return km, nil
+}
+
+// ParseChord: "Ctrl+Shift+S", "F3", "Ctrl+=", "Ctrl++" 같은 문자열 해석
+func ParseChord(s string) (Chord, error) {
+	var parts []string
+	if strings.HasSuffix(s, "++") {
+		// 키 자체가 '+'인 경우
+		parts = append(strings.Split(strings.TrimSuffix(s, "++"), "+"), "+")
+	} else {
+		parts = strings.Split(s, "+")
+	}
+	var chord Chord
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			switch strings.ToLower(part) {
+			case "ctrl", "control":
+				chord.Mods |= ModCtrl
+			case "shift":
+				chord.Mods |= ModShift
+			case "alt", "meta":
+				chord.Mods |= ModAlt
+			default:
+				return Chord{}, fmt.Errorf("%q: 알 수 없는 수식키 %q", s, part)
+			}
+			continue
+		}
+		key, err := parseKeyName(part)
+		if err != nil {
+			return Chord{}, fmt.Errorf("%q: %v", s, err)
+		}
+		chord.Key = key
+	}
+	return normalizeChord(chord), nil
+}
+
+func parseKeyName(name string) (rune, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		// "Ctrl+S"와 "Ctrl+s"는 같은 키. Shift는 명시해야 함
+		r, _ := utf8.DecodeRuneInString(name)
+		return unicode.ToLower(r), nil
+	}
+	lower := strings.ToLower(name)
+	if key, ok := namedKeys[lower]; ok {
+		return key, nil
+	}
+	var n int
+	if _, err := fmt.Sscanf(lower, "f%d", &n); err == nil && n >= 1 && n <= 12 {
+		return KeyF1 + rune(n-1), nil
+	}
+	return 0, fmt.Errorf("알 수 없는 키 %q", name)
+}
+
+// normalizeChord: 글자는 소문자로, 기호는 Shift 제거
+// 특수키(KeySym 값 그대로인 0xFF00 이상)는 그대로 둠
+func normalizeChord(c Chord) Chord {
+	if c.Key >= specialKeyBase {
+		return c
+	}
+	if unicode.IsLetter(c.Key) {
+		if unicode.IsUpper(c.Key) {
+			c.Mods |= ModShift
+		}
+		c.Key = unicode.ToLower(c.Key)
+	} else if unicode.IsPrint(c.Key) {
+		c.Mods &^= ModShift
+	}
+	return c
+}
+
+// commandForChord: 바인딩된 액션이 있으면 해당 명령
+func (km Keymap) commandForChord(c Chord) (Command, bool) {
+	action, ok := km[normalizeChord(c)]
+	if !ok {
+		return Command{}, false
+	}
+	return Command{
+		Code:  actionCommands[action],
+		Input: CharInput{c.Key},
+	}, true
+}
@@ -0,0 +1,199 @@
+package commander
+
+import (
+	"sort"
+	"strings"
+)
+
+// MinibufferResult: 입력 하나를 처리한 결과
+type MinibufferResult int
+
+const (
+	MinibufferEditing MinibufferResult = iota // 계속 입력중
+	MinibufferSubmit                          // Enter: Text()를 실행
+	MinibufferCancel                          // ESC(quit 액션): 닫기
+)
+
+// 명령줄 히스토리 최대 개수
+const maxMinibufferHistory = 100
+
+// Completer: 명령 이름 다음 인자의 후보 (예: theme -> 테마 이름들)
+type Completer func(command, arg string) []string
+
+// Minibuffer: 한 줄짜리 명령 입력창
+// 키 입력을 Command로 받아서 자체 편집 라인을 고침. 메인 고루틴에서만 사용
+type Minibuffer struct {
+	prompt string
+	line   []rune
+	cursor int
+	active bool
+
+	history []string
+	histPos int    // len(history)이면 새 입력줄
+	draft   string // 히스토리 탐색 전에 쓰던 내용
+
+	names     []string // 첫 단어 자동완성 후보
+	completer Completer
+}
+
+// NewMinibuffer: prompt를 앞에 표시하는 명령줄. names는 명령 이름 자동완성 후보
+func NewMinibuffer(prompt string, names []string) *Minibuffer {
+	return &Minibuffer{prompt: prompt, names: names}
+}
+
+// SetCompleter: 인자 자동완성 함수 지정
+func (m *Minibuffer) SetCompleter(c Completer) {
+	m.completer = c
+}
+
+func (m *Minibuffer) Open() {
+	m.active = true
+	m.line = m.line[:0]
+	m.cursor = 0
+	m.histPos = len(m.history)
+	m.draft = ""
+}
+
+// OpenWith: text를 미리 채워서 열기 (선택 영역이 있으면 '<,'> 등)
+func (m *Minibuffer) OpenWith(text string) {
+	m.Open()
+	m.setText(text)
+}
+
+func (m *Minibuffer) Close() {
+	m.active = false
+}
+
+func (m *Minibuffer) Active() bool   { return m.active }
+func (m *Minibuffer) Prompt() string { return m.prompt }
+func (m *Minibuffer) Text() string   { return string(m.line) }
+
+// Cursor: 편집 라인 안의 커서 위치 (글자 단위)
+func (m *Minibuffer) Cursor() int { return m.cursor }
+
+// History: 실행했던 명령들 (오래된 것부터)
+func (m *Minibuffer) History() []string { return m.history }
+
+// HandleCommand: 키 입력 Command로 편집 라인을 고침
+func (m *Minibuffer) HandleCommand(cmd Command) MinibufferResult {
+	if cmd.Code == CmdExit {
+		// ESC(quit 바인딩)는 에디터 종료 대신 명령줄만 닫음
+		m.Close()
+		return MinibufferCancel
+	}
+	charInput, ok := cmd.Input.(CharInput)
+	if !ok {
+		return MinibufferEditing
+	}
+	switch cmd.Code {
+	case CmdInsert:
+		switch charInput.Char {
+		case KeyEnter1, KeyEnter2:
+			m.submit()
+			return MinibufferSubmit
+		case KeyTab:
+			m.complete()
+		default:
+			m.insert(charInput.Char)
+		}
+	case CmdDelete:
+		if m.cursor > 0 {
+			m.line = append(m.line[:m.cursor-1], m.line[m.cursor:]...)
+			m.cursor--
+		}
+	case CmdMove:
+		switch charInput.Char {
+		case KeyLeft:
+			m.cursor = max(m.cursor-1, 0)
+		case KeyRight:
+			m.cursor = min(m.cursor+1, len(m.line))
+		case KeyUp:
+			m.recall(-1)
+		case KeyDown:
+			m.recall(1)
+		}
+	}
+	return MinibufferEditing
+}
+
+func (m *Minibuffer) insert(r rune) {
+	m.line = append(m.line[:m.cursor], append([]rune{r}, m.line[m.cursor:]...)...)
+	m.cursor++
+}
+
+func (m *Minibuffer) setText(text string) {
+	m.line = []rune(text)
+	m.cursor = len(m.line)
+}
+
+// submit: 입력을 히스토리에 남기고 닫음 (직전과 같은 명령은 한 번만)
+func (m *Minibuffer) submit() {
+	text := strings.TrimSpace(m.Text())
+	if text != "" && (len(m.history) == 0 || m.history[len(m.history)-1] != text) {
+		m.history = append(m.history, text)
+		if len(m.history) > maxMinibufferHistory {
+			m.history = m.history[1:]
+		}
+	}
+	m.Close()
+}
+
+// recall: 위/아래 화살표로 히스토리 탐색
+func (m *Minibuffer) recall(delta int) {
+	pos := m.histPos + delta
+	if pos < 0 || pos > len(m.history) {
+		return
+	}
+	if m.histPos == len(m.history) {
+		m.draft = m.Text()
+	}
+	m.histPos = pos
+	if pos == len(m.history) {
+		m.setText(m.draft)
+	} else {
+		m.setText(m.history[pos])
+	}
+}
+
+// complete: 커서 앞 단어를 후보들의 공통 접두어까지 채움
+// 첫 단어는 명령 이름, 그 뒤는 completer가 주는 인자 후보
+func (m *Minibuffer) complete() {
+	before := string(m.line[:m.cursor])
+	command, arg, hasArg := strings.Cut(before, " ")
+	var candidates []string
+	prefix := command
+	if !hasArg {
+		candidates = m.names
+	} else if m.completer != nil {
+		prefix = strings.TrimLeft(arg, " ")
+		candidates = m.completer(command, prefix)
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	sort.Strings(matches)
+	completion := commonPrefix(matches)
+	if len(matches) == 1 && !hasArg {
+		completion += " "
+	}
+	for _, r := range strings.TrimPrefix(completion, prefix) {
+		m.insert(r)
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
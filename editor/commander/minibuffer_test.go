@@ -0,0 +1,77 @@
+package commander
+
+import (
+	"reflect"
+	"testing"
+)
+
+func typeInto(m *Minibuffer, text string) {
+	for _, r := range text {
+		m.HandleCommand(Command{Code: CmdInsert, Input: CharInput{r}})
+	}
+}
+
+func TestMinibufferCompletionAndHistory(t *testing.T) {
+	m := NewMinibuffer(":", ExCommandNames())
+	m.SetCompleter(func(command, arg string) []string {
+		if command == "theme" {
+			return []string{"dark", "light"}
+		}
+		return nil
+	})
+	m.Open()
+	typeInto(m, "go")
+	m.HandleCommand(Command{Code: CmdInsert, Input: CharInput{KeyTab}})
+	if m.Text() != "goto " {
+		t.Fatalf("명령 이름 자동완성 = %q", m.Text())
+	}
+	typeInto(m, "12")
+	if res := m.HandleCommand(Command{Code: CmdInsert, Input: CharInput{KeyEnter1}}); res != MinibufferSubmit || m.Active() {
+		t.Fatalf("Enter 결과 = %v, active=%v", res, m.Active())
+	}
+
+	m.Open()
+	typeInto(m, "theme d")
+	m.HandleCommand(Command{Code: CmdInsert, Input: CharInput{KeyTab}})
+	if m.Text() != "theme dark" {
+		t.Fatalf("인자 자동완성 = %q", m.Text())
+	}
+	m.HandleCommand(Command{Code: CmdMove, Input: CharInput{KeyUp}})
+	if m.Text() != "goto 12" {
+		t.Fatalf("히스토리 = %q", m.Text())
+	}
+	m.HandleCommand(Command{Code: CmdMove, Input: CharInput{KeyDown}})
+	if m.Text() != "theme dark" {
+		t.Fatalf("히스토리에서 돌아온 입력 = %q", m.Text())
+	}
+	if res := m.HandleCommand(Command{Code: CmdExit}); res != MinibufferCancel {
+		t.Fatalf("ESC 결과 = %v", res)
+	}
+}
+
+func TestParseEx(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Command
+	}{
+		{"wq", []Command{{Code: CmdSave}, {Code: CmdExit}}},
+		{"120", []Command{{Code: CmdGoto, Input: LineInput{Line: 120}}}},
+		{"e notes.txt", []Command{{Code: CmdOpen, Input: PathInput{Path: "notes.txt"}}}},
+		{"set tabwidth = 8", []Command{{Code: CmdSet, Input: SettingInput{Name: "tabwidth", Value: "8"}}}},
+		{`s/a\/b/$1/g`, []Command{{Code: CmdSubstitute, Input: SubstituteInput{Pattern: "a/b", Replacement: "$1", Global: true}}}},
+		{"s#x#y", []Command{{Code: CmdSubstitute, Input: SubstituteInput{Pattern: "x", Replacement: "y"}}}},
+		{"%s/x/y/gc", []Command{{Code: CmdSubstitute, Input: SubstituteInput{Pattern: "x", Replacement: "y", Global: true, Confirm: true, Range: RangeDocument}}}},
+		{"'<,'>s/x/y/i", []Command{{Code: CmdSubstitute, Input: SubstituteInput{Pattern: "x", Replacement: "y", IgnoreCase: true, Range: RangeSelection}}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseEx(tt.line)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEx(%q) = %v, %v; want %v", tt.line, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"nope", "goto x", "s/a", "w extra"} {
+		if _, err := ParseEx(bad); err == nil {
+			t.Errorf("ParseEx(%q): 에러가 없음", bad)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_editor/editor/commander"
+	"go_editor/editor/handlefile"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config: 에디터 설정 (config.json)
+type Config struct {
+	Theme       string            `json:"theme"`
+	Colors      Colors            `json:"colors"`
+	LineHeight  int               `json:"line_height"`
+	Window      Window            `json:"window"`
+	FPS         int               `json:"fps"`
+	Cursor      Cursor            `json:"cursor"`
+	TabWidth    int               `json:"tab_width"`
+	LineNumbers string            `json:"line_numbers"` // off, absolute, relative
+	Keybindings map[string]string `json:"keybindings"`
+	// 이 크기(MB) 이상인 파일은 대용량 파일 모드(읽기 전용, 줄을 필요한 만큼만 만듦)로 엶. 0이면 안 씀
+	LargeFileMB int `json:"large_file_mb"`
+}
+
+// Colors: 테마 색을 덮어쓸 때만 지정 (nil이면 테마 색 사용)
+type Colors struct {
+	Foreground *Color `json:"foreground"`
+	Background *Color `json:"background"`
+	Cursor     *Color `json:"cursor"`
+}
+
+type Window struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type Cursor struct {
+	Shape string `json:"shape"` // bar, block, underline
+	// 깜빡임 주기(ms). 0이면 깜빡이지 않음
+	BlinkRateMs int `json:"blink_rate_ms"`
+}
+
+// 커서 모양
+const (
+	CursorBar       = "bar"
+	CursorBlock     = "block"
+	CursorUnderline = "underline"
+)
+
+// 줄 번호 표시 방식
+const (
+	LineNumbersOff      = "off"
+	LineNumbersAbsolute = "absolute"
+	LineNumbersRelative = "relative"
+)
+
+const (
+	configFileName  = "config.json"
+	projectFileName = ".go_editor.json"
+)
+
+// Default: 설정 파일이 없을 때의 값 (기존 하드코딩 값과 동일)
+func Default() *Config {
+	return &Config{
+		Theme:      "light",
+		LineHeight: 16,
+		Window:     Window{Width: 800, Height: 600},
+		FPS:        30,
+		Cursor: Cursor{
+			Shape:       CursorBar,
+			BlinkRateMs: 1000,
+		},
+		TabWidth:    4,
+		LineNumbers: LineNumbersOff,
+		Keybindings: commander.DefaultBindings(),
+		LargeFileMB: 256,
+	}
+}
+
+// Sources: 설정을 읽을 파일들. 뒤의 것이 앞의 것을 덮어씀
+type Sources struct {
+	Global  string // $XDG_CONFIG_HOME/go_editor/config.json
+	Project string // 열린 파일에서 위로 올라가며 찾은 .go_editor.json (없으면 "")
+}
+
+// Paths: 존재 여부와 상관 없이 감시할 경로들
+func (s Sources) Paths() []string {
+	paths := []string{s.Global}
+	if s.Project != "" {
+		paths = append(paths, s.Project)
+	}
+	return paths
+}
+
+// GlobalPath: 사용자 설정 파일 경로
+func GlobalPath() string {
+	return filepath.Join(handlefile.ConfigDir(), configFileName)
+}
+
+// Locate: 전역 설정과 filePath가 속한 프로젝트의 설정 파일 찾기
+func Locate(filePath string) Sources {
+	return Sources{
+		Global:  GlobalPath(),
+		Project: findProjectConfig(filePath),
+	}
+}
+
+// findProjectConfig: 파일이 있는 디렉토리부터 루트까지 .go_editor.json 탐색
+func findProjectConfig(filePath string) string {
+	if filePath == "" {
+		return ""
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Dir(absPath)
+	for {
+		candidate := filepath.Join(dir, projectFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load: 기본값 위에 전역 설정, 프로젝트 설정 순으로 덮어쓴 뒤 검증
+// 파일이 없으면 건너뛰고, 문법/검증 오류는 에러로 돌려줌
+func Load(src Sources) (*Config, error) {
+	cfg := Default()
+	for _, path := range src.Paths() {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.merge(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// merge: JSON에 있는 필드만 덮어씀 (keybindings는 키 단위로 합쳐짐)
+func (c *Config) merge(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// 오타난 키는 조용히 무시하지 않고 알려줌
+	dec.DisallowUnknownFields()
+	return dec.Decode(c)
+}
+
+// Validate: 값 범위 확인. 모든 오류를 모아서 돌려줌
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, &FieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+		}
+	}
+	check(c.LineHeight >= 8 && c.LineHeight <= 128, "line_height", "8~128 사이여야 합니다 (현재 %d)", c.LineHeight)
+	check(c.Window.Width >= 64 && c.Window.Width <= 8192, "window.width", "64~8192 사이여야 합니다 (현재 %d)", c.Window.Width)
+	check(c.Window.Height >= 64 && c.Window.Height <= 8192, "window.height", "64~8192 사이여야 합니다 (현재 %d)", c.Window.Height)
+	// 문서 한 줄 + 상태 표시줄 한 줄은 들어가야 함
+	check(c.Window.Height >= 2*c.LineHeight, "window.height", "line_height(%d)의 두 배 이상이어야 합니다", c.LineHeight)
+	check(c.FPS >= 1 && c.FPS <= 240, "fps", "1~240 사이여야 합니다 (현재 %d)", c.FPS)
+	check(c.TabWidth >= 1 && c.TabWidth <= 16, "tab_width", "1~16 사이여야 합니다 (현재 %d)", c.TabWidth)
+	check(c.Cursor.BlinkRateMs >= 0, "cursor.blink_rate_ms", "0 이상이어야 합니다 (현재 %d)", c.Cursor.BlinkRateMs)
+	check(c.LargeFileMB >= 0, "large_file_mb", "0 이상이어야 합니다 (현재 %d)", c.LargeFileMB)
+	switch c.Cursor.Shape {
+	case CursorBar, CursorBlock, CursorUnderline:
+	default:
+		check(false, "cursor.shape", "%q: bar, block, underline 중 하나여야 합니다", c.Cursor.Shape)
+	}
+	switch c.LineNumbers {
+	case LineNumbersOff, LineNumbersAbsolute, LineNumbersRelative:
+	default:
+		check(false, "line_numbers", "%q: off, absolute, relative 중 하나여야 합니다", c.LineNumbers)
+	}
+	if _, err := commander.ParseKeymap(c.Keybindings); err != nil {
+		check(false, "keybindings", "%v", err)
+	}
+	return errors.Join(errs...)
+}
+
+// Keymap: 검증된 키 바인딩
+func (c *Config) Keymap() commander.Keymap {
+	km, err := commander.ParseKeymap(c.Keybindings)
+	if err != nil {
+		// Validate를 통과한 설정이면 오지 않음
+		km, _ = commander.ParseKeymap(commander.DefaultBindings())
+	}
+	return km
+}
+
+// FieldError: 설정 항목 하나의 검증 오류
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return "설정 " + e.Field + ": " + e.Msg
+}
+
+// Color: "#RRGGBB" 또는 "#AARRGGBB" 형식의 색 (ARGB uint32)
+type Color uint32
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("#%06X", uint32(c)&0xFFFFFF))
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("색은 \"#RRGGBB\" 형식의 문자열이어야 합니다")
+	}
+	color, err := ParseColor(s)
+	if err != nil {
+		return err
+	}
+	*c = color
+	return nil
+}
+
+// ParseColor: "#RRGGBB" / "#AARRGGBB" 해석 (알파 생략시 불투명)
+func ParseColor(s string) (Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	var v uint32
+	if _, err := fmt.Sscanf(hex, "%x", &v); err != nil || (len(hex) != 6 && len(hex) != 8) {
+		return 0, fmt.Errorf("잘못된 색 %q (\"#RRGGBB\" 형식)", s)
+	}
+	if len(hex) == 6 {
+		v |= 0xFF000000
+	}
+	return Color(v), nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"go_editor/editor/commander"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMergesProjectOverride(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "xdg", "go_editor", "config.json")
+	writeFile(t, global, `{
+		"colors": {"foreground": "#EEEEEE", "background": "#101010"},
+		"tab_width": 8,
+		"keybindings": {"Ctrl+Q": "quit"}
+	}`)
+	project := filepath.Join(dir, "proj", projectFileName)
+	writeFile(t, project, `{"tab_width": 2, "cursor": {"shape": "block"}}`)
+
+	// 프로젝트 하위 파일 기준으로 찾아야 함
+	src := Sources{Global: global, Project: findProjectConfig(filepath.Join(dir, "proj", "src", "main.go"))}
+	if src.Project != project {
+		t.Fatalf("프로젝트 설정 탐색 = %q, want %q", src.Project, project)
+	}
+	cfg, err := Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TabWidth != 2 {
+		t.Errorf("tab_width = %d, 프로젝트 설정(2)이 우선해야 함", cfg.TabWidth)
+	}
+	if cfg.Colors.Foreground == nil || *cfg.Colors.Foreground != 0xFFEEEEEE ||
+		cfg.Colors.Background == nil || *cfg.Colors.Background != 0xFF101010 || cfg.Colors.Cursor != nil {
+		t.Errorf("colors = %+v", cfg.Colors)
+	}
+	if cfg.Cursor.Shape != CursorBlock || cfg.Cursor.BlinkRateMs != 1000 {
+		t.Errorf("cursor = %+v, 지정하지 않은 값은 기본값이어야 함", cfg.Cursor)
+	}
+	// 기본 바인딩에 사용자 바인딩이 합쳐짐
+	km := cfg.Keymap()
+	if km[commander.Chord{Mods: commander.ModCtrl, Key: 'q'}] != "quit" || km[commander.Chord{Key: commander.KeyESC}] != "quit" {
+		t.Errorf("keymap = %v", km)
+	}
+}
+
+func TestValidateCollectsErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeFile(t, path, `{
+		"fps": 0,
+		"tab_width": 40,
+		"cursor": {"shape": "triangle"},
+		"keybindings": {"Hyper+X": "quit", "Ctrl+K": "explode"}
+	}`)
+	_, err := Load(Sources{Global: path})
+	if err == nil {
+		t.Fatal("잘못된 설정이 통과함")
+	}
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("FieldError가 아님: %v", err)
+	}
+	for _, field := range []string{"fps", "tab_width", "cursor.shape", "Hyper", "explode"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("오류 메시지에 %q가 없음:\n%v", field, err)
+		}
+	}
+}
+
+func TestUnknownFieldAndBadColor(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeFile(t, path, `{"tabwidth": 4}`)
+	if _, err := Load(Sources{Global: path}); err == nil {
+		t.Error("알 수 없는 키가 통과함")
+	}
+	writeFile(t, path, `{"colors": {"foreground": "red"}}`)
+	if _, err := Load(Sources{Global: path}); err == nil {
+		t.Error("잘못된 색이 통과함")
+	}
+}
+
+func TestWatchConfigCreatedAfterStart(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "xdg", "go_editor", "config.json")
+	w := Watch(Sources{Global: global})
+	defer w.Close()
+
+	wait := func() bool {
+		select {
+		case <-w.Events():
+			return true
+		case <-time.After(3 * time.Second):
+			return false
+		}
+	}
+
+	// 설정 디렉토리부터 없는 상태에서 처음 만듦
+	writeFile(t, global, `{"tab_width": 2}`)
+	if !wait() {
+		t.Fatal("새로 만든 설정 파일을 알리지 않음")
+	}
+
+	// 다시 건 감시로 이후 수정도 알림
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case <-w.Events():
+	default:
+	}
+	writeFile(t, global, `{"tab_width": 8}`)
+	if !wait() {
+		t.Fatal("만든 뒤의 수정을 알리지 않음")
+	}
+
+	w.Close()
+	w.Close()
+}
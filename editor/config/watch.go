@@ -0,0 +1,105 @@
+package config
+
+import (
+	"go_editor/editor/handlefile"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Watcher: 설정 파일들의 변경을 하나의 채널로 모아서 알림
+type Watcher struct {
+	events chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closed sync.Once
+}
+
+// Watch: src의 설정 파일들을 감시
+// 디렉토리가 아직 없으면 있는 가장 가까운 조상에서 기다렸다가, 생기면 그 안으로 다시 감시함
+// (에디터 실행 중에 $XDG_CONFIG_HOME/go_editor/config.json을 처음 만들어도 반영됨)
+// 프로젝트 설정은 파일을 열 때 찾은 것만 감시하므로, 새로 만든 .go_editor.json은 파일을 다시 열어야 반영됨
+func Watch(src Sources) *Watcher {
+	w := &Watcher{
+		events: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	// 처음 감시는 여기서 걸어야 Watch가 끝난 뒤의 변경을 놓치지 않음
+	for _, path := range src.Paths() {
+		target := watchTarget(path)
+		fw, err := handlefile.WatchFile(target)
+		if err != nil {
+			continue
+		}
+		w.wg.Add(1)
+		go w.follow(path, fw, target)
+	}
+	return w
+}
+
+// watchTarget: path의 디렉토리가 있으면 path, 없으면 있는 가장 가까운 조상 아래의 첫 번째 없는 디렉토리
+func watchTarget(path string) string {
+	target := path
+	for {
+		dir := filepath.Dir(target)
+		if _, err := os.Stat(dir); err == nil || dir == target {
+			return target
+		}
+		target = dir
+	}
+}
+
+// follow: path 하나를 감시. 디렉토리가 생기거나 지워져서 감시할 대상이 바뀌면 다시 걸어둠
+func (w *Watcher) follow(path string, fw *handlefile.Watcher, target string) {
+	defer w.wg.Done()
+	for w.forward(fw, path, target) {
+		fw.Close()
+		target = watchTarget(path)
+		var err error
+		if fw, err = handlefile.WatchFile(target); err != nil {
+			return
+		}
+		// 디렉토리와 파일이 새 감시를 걸기 전에 같이 생겼을 수 있음
+		if _, err := os.Stat(path); err == nil {
+			w.notify()
+		}
+	}
+	fw.Close()
+}
+
+// forward: fw의 알림을 넘겨줌. 감시 대상이 바뀌면 true, 닫히면 false
+func (w *Watcher) forward(fw *handlefile.Watcher, path, target string) bool {
+	for {
+		select {
+		case <-w.done:
+			return false
+		case <-fw.Events():
+			if target != path || watchTarget(path) != path {
+				return true
+			}
+			w.notify()
+		}
+	}
+}
+
+// notify: 이미 대기중인 알림이 있으면 버림
+func (w *Watcher) notify() {
+	select {
+	case w.events <- struct{}{}:
+	default:
+	}
+}
+
+// Events: 설정 파일 중 하나라도 바뀌면 신호
+func (w *Watcher) Events() <-chan struct{} {
+	return w.events
+}
+
+// Close: 감시 중지 (여러 번 불러도 됨)
+func (w *Watcher) Close() error {
+	w.closed.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+	return nil
+}
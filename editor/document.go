@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"go_editor/editor/config"
+	"go_editor/editor/storage"
+	"go_editor/editor/syncer"
+	"log/slog"
+	"path/filepath"
+)
+
+// openDocument: 경로의 파일로 SyncProtocol 생성
+// 파일이 없거나 비어있으면 새 문서, 있으면 내용을 불러옴
+// largeFileMB 이상인 파일은 대용량 파일 모드로 엶 (0이면 항상 일반 모드)
+func openDocument(logger *slog.Logger, path string, width, height int, fg, bg uint32, lineHeight, largeFileMB int) *syncer.SyncProtocol {
+	// .gz 파일은 압축 투명 저장소로 열림
+	st := storage.Open(path)
+
+	// 파일 존재 여부 및 내용 확인
+	fileInfo, err := st.Stat()
+	if err != nil || !fileInfo.Exists || fileInfo.Size == 0 {
+		// 파일이 없거나 비어있으면 NewSyncProtocol 호출
+		if err == nil && !fileInfo.Exists {
+			logger.Info("파일이 없어 새 문서 생성", "path", path)
+		} else if err == nil && fileInfo.Size == 0 {
+			logger.Info("빈 파일이라 새 문서 생성", "path", path)
+		} else {
+			logger.Warn("파일 접근 오류, 새 문서 생성", "path", path, "err", err)
+		}
+		return syncer.NewSyncProtocol(st, width, height, fg, bg, lineHeight)
+	}
+	if largeFileMB > 0 && fileInfo.Size >= int64(largeFileMB)<<20 {
+		// 일반 파일은 -mmap이 없어도 매핑해서 메모리에 복사하지 않음
+		if _, plain := st.(*storage.FileStorage); plain {
+			st = storage.NewMappedFileStorage(path)
+		}
+		logger.Info("대용량 파일 모드로 엶", "path", path, "bytes", fileInfo.Size)
+		return syncer.OpenLargeFile(st, width, height, fg, bg, lineHeight)
+	}
+	// 파일이 존재하고 내용이 있으면 LoadSyncProtocol 호출
+	logger.Info("기존 파일 불러옴", "path", path, "bytes", fileInfo.Size)
+	return syncer.LoadSyncProtocol(st, width, height, fg, bg, lineHeight)
+}
+
+// watchDocument: 외부 변경 감시 (실패해도 편집은 가능하므로 nil)
+func watchDocument(logger *slog.Logger, sp *syncer.SyncProtocol) storage.Watcher {
+	watcher, err := sp.WatchStorage()
+	if err != nil {
+		logger.Warn("파일 변경 감시를 시작할 수 없음", "err", err)
+		return nil
+	}
+	return watcher
+}
+
+// openFile: 현재 문서를 닫고 다른 파일을 엶 (명령줄의 e path)
+// 저장하지 않은 변경이 있으면 거부. 테마/배율/표시 설정은 그대로 이어감
+func (e *Editor) openFile(path string) {
+	if e.syncProtocol.IsDirty() {
+		e.syncProtocol.SetMessage("Unsaved changes, save first (w)")
+		return
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		e.logger.Warn("잘못된 경로", "path", path, "err", err)
+		e.syncProtocol.SetMessage("Bad path: %s", syncer.StatusError(err))
+		return
+	}
+
+	prev := e.syncProtocol
+	sp := openDocument(e.logger, absPath, e.docWidth, e.docHeight, 0, 0, e.config.LineHeight, e.config.LargeFileMB)
+	if t := prev.Theme(); t != nil {
+		sp.SetTheme(t)
+	}
+	sp.SetCursorShape(cursorShape(e.config.Cursor.Shape))
+	sp.SetTabWidth(prev.TabWidth())
+	sp.SetGutterMode(prev.GutterMode())
+	sp.SetScale(prev.Scale())
+
+	if e.watcher != nil {
+		e.watcher.Close()
+	}
+	prev.Close()
+	e.syncProtocol = sp
+	e.filePath = absPath
+	e.watcher = watchDocument(e.logger, sp)
+	e.setTitle(filepath.Base(absPath))
+
+	// 프로젝트 설정은 파일 위치 기준이라 새로 찾음
+	e.configWatcher.Close()
+	e.configSources = config.Locate(absPath)
+	e.configWatcher = config.Watch(e.configSources)
+	e.reloadConfig()
+	sp.SetMessage("Opened %s", filepath.Base(absPath))
+}
@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"go_editor/editor/commander"
+	"go_editor/editor/config"
+	"go_editor/editor/logging"
+	"go_editor/editor/theme"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 창 없이 문서/감시자만 있는 에디터 (X 연결 없이 openFile, shutdown 확인용)
+func newHeadlessEditor(t *testing.T, path string) *Editor {
+	t.Helper()
+	cfg := config.Default()
+	logger := logging.Discard()
+	sp := openDocument(logger, path, cfg.Window.Width, cfg.Window.Height-cfg.LineHeight, 0, 0, cfg.LineHeight, cfg.LargeFileMB)
+	src := config.Locate(path)
+	e := &Editor{
+		commander:     commander.NewCommandor(nil),
+		fpsTicker:     time.NewTicker(time.Second),
+		blinkTicker:   time.NewTicker(time.Second),
+		syncProtocol:  sp,
+		docWidth:      cfg.Window.Width,
+		docHeight:     cfg.Window.Height - cfg.LineHeight,
+		filePath:      path,
+		watcher:       watchDocument(logger, sp),
+		config:        cfg,
+		configSources: src,
+		configWatcher: config.Watch(src),
+		themes:        theme.NewRegistry(),
+		logger:        logger,
+	}
+	t.Cleanup(func() {
+		e.fpsTicker.Stop()
+		e.blinkTicker.Stop()
+	})
+	return e
+}
+
+func TestOpenFileThenShutdown(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	if err := os.WriteFile(first, []byte("first"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("second"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newHeadlessEditor(t, first)
+	e.openFile(second)
+	if e.filePath != second {
+		t.Fatalf("열린 파일 = %q", e.filePath)
+	}
+	e.syncProtocol.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'X'}})
+
+	// 처음 문서의 설정 감시자는 openFile에서 이미 닫혔으므로 다시 닫아도 panic이 나면 안 됨
+	e.shutdown()
+
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Xsecond" {
+		t.Fatalf("종료 시 새 문서가 저장되지 않음: %q", data)
+	}
+	data, _ = os.ReadFile(first)
+	if string(data) != "first" {
+		t.Fatalf("처음 문서가 바뀜: %q", data)
+	}
+	select {
+	case <-e.watchEvents():
+		t.Fatal("닫힌 감시자에서 이벤트")
+	default:
+	}
+}
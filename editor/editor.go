@@ -2,13 +2,17 @@ package editor
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 
 	"go_editor/editor/commander"
-	"go_editor/editor/handlefile"
+	"go_editor/editor/config"
+	"go_editor/editor/logging"
 	"go_editor/editor/screener"
+	"go_editor/editor/storage"
 	"go_editor/editor/syncer"
+	"go_editor/editor/theme"
 	"time"
 
 	"github.com/BurntSushi/xgbutil"
@@ -33,54 +37,106 @@ type Editor struct {
 	xu            *xgbutil.XUtil
 
 	syncProtocol *syncer.SyncProtocol
+	// 문서 영역 크기 (다른 파일을 열 때 같은 크기로 만듦)
+	docWidth, docHeight int
+
+	// 열린 파일 외부 변경 감시
+	filePath      string
+	watcher       storage.Watcher
+	reloadPending bool // 외부 변경에 대한 사용자 응답 대기중
+
+	// 설정 (파일이 바뀌면 다시 읽어서 적용)
+	config        *config.Config
+	configSources config.Sources
+	configWatcher *config.Watcher
+
+	// 테마 (기본 + 사용자 테마 디렉토리)
+	themes *theme.Registry
+
+	// Ctrl+P 명령줄 (열려 있으면 키 입력이 문서 대신 여기로 감)
+	minibuffer *commander.Minibuffer
+	// Ctrl+F 검색 프롬프트 (입력할 때마다 검색)
+	findPrompt *commander.Minibuffer
+	// 매치마다 확인하는 치환 (s///c) 진행중이면 nil이 아님
+	replaceSession *syncer.ReplaceSession
+
+	logger *slog.Logger
 }
 
+// 외부 변경 프롬프트 문구 (상태 표시줄은 ASCII 글리프만 있음)
+const (
+	reloadPromptTitle  = "⚠️ 파일이 외부에서 변경됨 - [r] 다시 불러오기 / [k] 내 버전 유지 / [d] 차이 보기"
+	reloadPromptLog    = "⚠️ 수정 중인 파일이 외부에서 변경되었습니다: r=다시 불러오기, k=유지, d=차이 보기"
+	reloadPromptStatus = "CHANGED ON DISK: [r]eload [k]eep [d]iff"
+)
+
+// 상태 표시줄의 모드 이름
+const (
+	modeEdit = "EDIT"
+	modeView = "VIEW" // 대용량 파일 (읽기 전용)
+)
+
 // NewEditor: Editor 인스턴스 생성
-func NewEditor(width, height int, fps int) (*Editor, error) {
+// savePath는 열 파일의 절대 경로 (handlefile.ResolveOpenPath)
+// 창 크기, FPS, 색 등은 cfg를 따르고, src의 설정 파일이 바뀌면 실행중에 다시 적용함
+// logger는 구성요소별 태그를 붙여 나눠 줌 (nil이면 출력 없음)
+func NewEditor(savePath string, cfg *config.Config, src config.Sources, logger *slog.Logger) (*Editor, error) {
+	editorLogger := logging.Component(logger, "editor")
+	syncer.SetDefaultLogger(logging.Component(logger, "syncer"))
+	width, height := cfg.Window.Width, cfg.Window.Height
+	themes := theme.NewRegistry()
+	for _, err := range themes.LoadDir(theme.UserDir()) {
+		editorLogger.Warn("테마 로드 실패", "err", err)
+	}
+	startTheme := resolveTheme(editorLogger, themes, cfg)
+	fg, bg := uint32(startTheme.Foreground), uint32(startTheme.Background)
 	xu, err := xgbutil.NewConn()
 	if err != nil {
 		return nil, fmt.Errorf("XGBUtil 연결 실패: %v", err)
 	}
-	savePath := handlefile.GetSaveTxtPath()
-	var syncProtocol *syncer.SyncProtocol
-
-	// 파일 존재 여부 및 내용 확인
-	fileInfo, err := os.Stat(savePath)
-	if err != nil || fileInfo.Size() == 0 {
-		// 파일이 없거나 비어있으면 NewSyncProtocol 호출
-		if os.IsNotExist(err) {
-			log.Printf("🆕 파일이 존재하지 않아 새 문서를 생성합니다: %s", savePath)
-		} else if err == nil && fileInfo.Size() == 0 {
-			log.Printf("🆕 파일이 비어있어 새 문서를 생성합니다: %s", savePath)
-		} else {
-			log.Printf("⚠️ 파일 접근 오류: %v, 새 문서를 생성합니다", err)
-		}
-		syncProtocol = syncer.NewSyncProtocol(width, height, 0xFF000000, 0xFFFFFFFF, 16)
-	} else {
-		// 파일이 존재하고 내용이 있으면 LoadSyncProtocol 호출
-		log.Printf("📄 기존 파일을 불러옵니다: %s (크기: %d 바이트)", savePath, fileInfo.Size())
-		syncProtocol = syncer.LoadSyncProtocol(width, height, 0xFF000000, 0xFFFFFFFF, 16)
-	}
-	scr, err := screener.NewScreener(xu, width, height, 0xFF000000, 0xFFFFFFFF)
+	// 맨 아래 한 줄은 상태 표시줄, 문서는 그 위 영역만 씀
+	statusHeight := cfg.LineHeight
+	docHeight := height - statusHeight
+	syncProtocol := openDocument(editorLogger, savePath, width, docHeight, fg, bg, cfg.LineHeight, cfg.LargeFileMB)
+	scr, err := screener.NewScreener(xu, width, height, fg, bg)
 	if err != nil {
 		return nil, err
 	}
+	scr.SetLogger(logging.Component(logger, "screener"))
+	scr.SetLineHeight(cfg.LineHeight)
+	scr.SetStatusHeight(statusHeight)
+
+	scr.SetTitle(filepath.Base(savePath))
 
 	// Commandor 생성
 	cmdor := commander.NewCommandor(xu)
+	cmdor.SetLogger(logging.Component(logger, "commander"))
 	e := &Editor{
 		screener:      scr,
 		commander:     cmdor, // Commandor 위임
 		xu:            xu,
-		fpsTicker:     time.NewTicker(time.Second / time.Duration(fps)), // 30FPS
-		blinkTicker:   time.NewTicker(time.Second * 1),                  // 1초 주기
+		fpsTicker:     time.NewTicker(time.Second / time.Duration(cfg.FPS)), // 30FPS
+		blinkTicker:   time.NewTicker(time.Second * 1),                      // 1초 주기
 		running:       true,
 		lines:         []string{"Hello", "KeyPress Count: 0"}, // 초기 2개 라인,
 		textCount:     0,
 		cursorVisible: false,
 
 		syncProtocol: syncProtocol,
+		docWidth:     width,
+		docHeight:    docHeight,
+		filePath:     savePath,
+		watcher:      watchDocument(editorLogger, syncProtocol),
+		minibuffer:   commander.NewMinibuffer(":", commander.ExCommandNames()),
+		findPrompt:   commander.NewMinibuffer("find", nil),
+
+		configSources: src,
+		configWatcher: config.Watch(src),
+		themes:        themes,
+		logger:        editorLogger,
 	}
+	e.minibuffer.SetCompleter(e.completeArgument)
+	e.applyConfig(cfg)
 	// X 키 바인딩 초기화
 	keybind.Initialize(xu)
 	return e, nil
@@ -88,7 +144,8 @@ func NewEditor(width, height int, fps int) (*Editor, error) {
 
 // Run: 메인 이벤트 루프
 func (e *Editor) Run() {
-	defer e.syncProtocol.SaveToFile()
+	// :e로 문서와 감시자가 바뀌므로 끝날 때의 것을 정리
+	defer func() { e.shutdown() }()
 
 	e.commander.StartListening()
 
@@ -101,30 +158,232 @@ func (e *Editor) Run() {
 		case <-e.fpsTicker.C:
 			// 30FPS로 화면 Flush
 
-			e.screener.FlushBuffer(e.syncProtocol.FlushLineBuffer())
+			e.screener.FlushBuffer(e.syncProtocol.FlushLineBuffer(), e.renderStatusBar())
 
 		case cmd, ok := <-e.commander.GetCommandChan():
 			if !ok {
 				e.running = false
 				break
 			}
-			e.processCommand(cmd)
+			e.processCommands(drainCommands(cmd, e.commander.GetCommandChan()))
+
+		case <-e.watchEvents():
+			e.handleExternalChange()
+
+		case <-e.configWatcher.Events():
+			e.reloadConfig()
+		}
+	}
+}
+
+// shutdown: 종료 시 정리 (지금 열린 문서와 감시자 기준)
+func (e *Editor) shutdown() {
+	// 자기 저장을 외부 변경으로 알리지 않도록 감시자를 먼저 닫음
+	if e.watcher != nil {
+		e.watcher.Close()
+	}
+	// 수정된 경우에만 저장 -> 외부에서 바뀐 파일을 깨끗한 버퍼로 덮어쓰지 않음
+	e.syncProtocol.SaveIfDirty()
+	e.syncProtocol.Close()
+	e.configWatcher.Close()
+}
+
+// setTitle: 창 제목 변경 (창 없이 만든 에디터면 무시)
+func (e *Editor) setTitle(title string) {
+	if e.screener != nil {
+		e.screener.SetTitle(title)
+	}
+}
+
+// watchEvents: 감시자가 없으면 nil 채널 (select에서 영원히 대기)
+func (e *Editor) watchEvents() <-chan struct{} {
+	if e.watcher == nil {
+		return nil
+	}
+	return e.watcher.Events()
+}
+
+// handleExternalChange: 열린 파일이 외부에서 바뀐 경우 처리
+// 버퍼가 깨끗하면 조용히 다시 불러오고, 수정중이면 사용자에게 물어봄
+func (e *Editor) handleExternalChange() {
+	changed, err := e.syncProtocol.FileChangedOnDisk()
+	if err != nil {
+		e.logger.Warn("변경된 파일 확인 실패", "err", err)
+		return
+	}
+	if !changed {
+		// 자기 자신의 저장이거나 내용이 같음
+		return
+	}
+	if !e.syncProtocol.IsDirty() {
+		e.reloadFromFile()
+		return
+	}
+	e.reloadPending = true
+	e.setTitle(reloadPromptTitle)
+	e.logger.Warn(reloadPromptLog, "path", e.filePath)
+}
+
+// answerReloadPrompt: 외부 변경 프롬프트에 대한 키 입력 처리
+func (e *Editor) answerReloadPrompt(cmd commander.Command) {
+	charInput, ok := cmd.Input.(commander.CharInput)
+	if !ok {
+		return
+	}
+	switch charInput.Char {
+	case 'r', 'R':
+		e.reloadFromFile()
+	case 'k', 'K', commander.KeyESC:
+		if err := e.syncProtocol.AcknowledgeFileChange(); err != nil {
+			e.logger.Warn("변경된 파일 확인 실패", "err", err)
+		}
+		e.finishReloadPrompt()
+	case 'd', 'D':
+		diff, err := e.syncProtocol.DiffWithFile()
+		if err != nil {
+			e.logger.Warn("차이 계산 실패", "err", err)
+			e.syncProtocol.SetMessage("Diff failed: %s", syncer.StatusError(err))
+			return
 		}
+		// 프롬프트는 유지 (차이를 본 후 r/k 선택)
+		// 사용자가 요청한 출력이므로 로그 설정과 상관없이 터미널에 보여줌
+		fmt.Fprintf(os.Stderr, "버퍼(-)와 디스크(+)의 차이:\n%s\n", diff)
+		e.syncProtocol.SetMessage("Diff printed to terminal")
 	}
 }
 
+func (e *Editor) reloadFromFile() {
+	e.syncProtocol.ClearCursor()
+	if err := e.syncProtocol.ReloadFromFile(); err != nil {
+		e.logger.Warn("파일 다시 불러오기 실패", "err", err)
+		e.syncProtocol.SetMessage("Reload failed: %s", syncer.StatusError(err))
+	} else {
+		e.syncProtocol.SetMessage("Reloaded from disk")
+	}
+	e.finishReloadPrompt()
+}
+
+func (e *Editor) finishReloadPrompt() {
+	e.reloadPending = false
+	e.setTitle(filepath.Base(e.filePath))
+}
+
+// mode: 상태 표시줄에 보여줄 현재 모드
+func (e *Editor) mode() string {
+	if e.reloadPending {
+		return reloadPromptStatus
+	}
+	if e.replaceSession != nil {
+		match, replacement := e.replaceSession.Current()
+		current, total := e.replaceSession.Progress()
+		return fmt.Sprintf("REPLACE %q WITH %q? (y/n/a/q) [%d/%d]", match, replacement, current, total)
+	}
+	if e.syncProtocol.LargeFile() {
+		return modeView
+	}
+	return modeEdit
+}
+
+// renderStatusBar: 문서 상태 + 에디터 모드로 상태 표시줄 픽셀 생성
+func (e *Editor) renderStatusBar() []uint32 {
+	if e.minibuffer.Active() {
+		return e.syncProtocol.RenderPromptBar(e.minibuffer.Prompt(), e.minibuffer.Text(), e.minibuffer.Cursor())
+	}
+	if e.findPrompt.Active() {
+		current, total := e.syncProtocol.SearchStatus()
+		prompt := fmt.Sprintf("%s [%d/%d]: ", e.findPrompt.Prompt(), current, total)
+		return e.syncProtocol.RenderPromptBar(prompt, e.findPrompt.Text(), e.findPrompt.Cursor())
+	}
+	status := e.syncProtocol.Status()
+	status.FileName = filepath.Base(e.filePath)
+	status.Mode = e.mode()
+	return e.syncProtocol.RenderStatusBar(status)
+}
+
+// maxCommandBatch: 한 번에 꺼내 처리할 최대 명령 수
+const maxCommandBatch = 64
+
+// drainCommands: 첫 명령 뒤로 이미 쌓여 있는 명령들을 기다리지 않고 함께 꺼냄
+func drainCommands(first commander.Command, ch <-chan commander.Command) []commander.Command {
+	cmds := []commander.Command{first}
+	for len(cmds) < maxCommandBatch {
+		select {
+		case cmd, ok := <-ch:
+			if !ok {
+				// 닫힌 건 다음 select에서 처리
+				return cmds
+			}
+			cmds = append(cmds, cmd)
+		default:
+			return cmds
+		}
+	}
+	return cmds
+}
+
+// processCommands: 꺼낸 명령들을 처리. 프롬프트가 없을 때 연속된 글자 입력은 한 번에 문서로
+func (e *Editor) processCommands(cmds []commander.Command) {
+	for i := 0; i < len(cmds) && e.running; {
+		j := i
+		if !e.promptActive() {
+			for j < len(cmds) && cmds[j].IsTyping() {
+				j++
+			}
+		}
+		if j-i > 1 {
+			e.applyToDocument(func() bool { return e.syncProtocol.ProcessCommands(cmds[i:j]) })
+			i = j
+			continue
+		}
+		e.processCommand(cmds[i])
+		i++
+	}
+}
+
+// promptActive: 키 입력을 문서 대신 받는 프롬프트가 떠 있는지
+func (e *Editor) promptActive() bool {
+	return e.reloadPending || e.replaceSession != nil || e.minibuffer.Active() || e.findPrompt.Active()
+}
+
 // processCommand: Command를 처리
 func (e *Editor) processCommand(cmd commander.Command) {
+	if e.reloadPending {
+		// 외부 변경 프롬프트가 떠 있는 동안엔 편집 대신 응답으로 처리
+		e.answerReloadPrompt(cmd)
+		return
+	}
+	if e.replaceSession != nil {
+		e.answerReplace(cmd)
+		return
+	}
+	if e.minibuffer.Active() {
+		e.handleMinibuffer(cmd)
+		return
+	}
+	if e.findPrompt.Active() {
+		e.handleFindPrompt(cmd)
+		return
+	}
+	if e.processEditorCommand(cmd) {
+		// 화면(스크리너)까지 바뀌는 명령은 에디터에서 처리
+		return
+	}
+	e.applyToDocument(func() bool { return e.syncProtocol.ProcessCommand(cmd) })
+}
+
+// applyToDocument: 커서를 지우고 문서 명령을 실행한 뒤 커서를 다시 그림
+func (e *Editor) applyToDocument(process func() bool) {
 	//레이어 2 수정
 	e.syncProtocol.ClearCursor()
 	//레이어 1 수정
-	isContinue := e.syncProtocol.ProcessCommand(cmd)
+	isContinue := process()
 	if !isContinue {
 		e.running = false
 		return
 	}
 	//레이어 2 수정
-	if e.syncProtocol.IsCursorVisible() {
+	// 깜빡이지 않는 설정이면 항상 다시 그림
+	if e.syncProtocol.IsCursorVisible() || e.config.Cursor.BlinkRateMs == 0 {
 		e.syncProtocol.CursorDrawOn()
 	}
 
@@ -0,0 +1,64 @@
+package editor
+
+import (
+	"go_editor/editor/commander"
+)
+
+// openFindPrompt: Ctrl+F. 이전 검색 강조는 지우고 현재 커서 위치에서 시작
+func (e *Editor) openFindPrompt() {
+	e.syncProtocol.StartSearch()
+	e.findPrompt.Open()
+}
+
+// handleFindPrompt: 검색 프롬프트가 열려 있을 때의 키 입력
+// 글자가 바뀔 때마다 다시 찾고, Enter는 위치를 유지, ESC는 시작 위치로 돌아감
+func (e *Editor) handleFindPrompt(cmd commander.Command) {
+	if cmd.Code == commander.CmdFindNext || cmd.Code == commander.CmdFindPrev {
+		// 프롬프트를 연 채로 F3 / Shift+F3
+		e.findNext(cmd.Code == commander.CmdFindNext)
+		return
+	}
+	before := e.findPrompt.Text()
+	switch e.findPrompt.HandleCommand(cmd) {
+	case commander.MinibufferSubmit:
+		e.syncProtocol.EndSearch(true)
+		e.reportMatches()
+	case commander.MinibufferCancel:
+		e.syncProtocol.EndSearch(false)
+	default:
+		if text := e.findPrompt.Text(); text != before {
+			e.syncProtocol.UpdateSearch(text)
+		}
+	}
+}
+
+// findNext: 다음/이전 매치로 이동. 문서 끝을 넘어가면 알려줌
+func (e *Editor) findNext(forward bool) {
+	found, wrapped := e.syncProtocol.FindNext(forward)
+	if !found {
+		if query := e.syncProtocol.SearchQuery(); query != "" {
+			e.syncProtocol.SetMessage("Pattern not found: %s", query)
+		} else {
+			e.syncProtocol.SetMessage("No search (Ctrl+F)")
+		}
+		return
+	}
+	if wrapped {
+		e.logger.Debug("검색이 문서 끝을 넘어 처음부터 계속")
+		e.syncProtocol.SetMessage("Search wrapped")
+		return
+	}
+	e.reportMatches()
+}
+
+// reportMatches: 매치 번호/개수를 상태 표시줄에
+func (e *Editor) reportMatches() {
+	current, total := e.syncProtocol.SearchStatus()
+	if total == 0 {
+		if query := e.syncProtocol.SearchQuery(); query != "" {
+			e.syncProtocol.SetMessage("Pattern not found: %s", query)
+		}
+		return
+	}
+	e.syncProtocol.SetMessage("Match %d of %d", current, total)
+}
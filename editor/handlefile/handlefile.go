@@ -1,105 +1,95 @@
 package handlefile
 
 import (
-	"log"
+	"errors"
+	"go_editor/editor/logging"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 
 	"github.com/joho/godotenv" // godotenv 라이브러리 사용
 )
 
-// ✅ `init()`을 사용해 자동 실행
-func init() {
-	LoadEnv()
-}
+// 설정/데이터 디렉토리 아래에 쓰는 앱 디렉토리 이름
+const AppDirName = "go_editor"
 
-// ✅ Git 루트 디렉토리 찾기
-func GetProjectRoot() string {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
-	if err != nil {
-		log.Printf("Git 루트 디렉토리를 찾을 수 없습니다: %v", err)
-		// 현재 작업 디렉토리를 대체로 사용
-		currentDir, err := os.Getwd()
-		if err != nil {
-			log.Fatal("현재 디렉토리를 확인할 수 없습니다.")
-		}
-		log.Printf("현재 작업 디렉토리를 프로젝트 루트로 사용합니다: %s", currentDir)
-		return currentDir
-	}
-	return string(output[:len(output)-1]) // 개행 문자 제거
+// 파일을 지정하지 않았을 때 여는 기본 파일 이름
+const defaultFileName = "saved.txt"
+
+// logger: 환경변수/경로 결정 로그 (기본은 출력 없음)
+var logger = logging.Discard()
+
+// SetLogger: LoadEnv, ResolveOpenPath 전에 호출
+func SetLogger(l *slog.Logger) {
+	logger = logging.Component(l, "handlefile")
 }
 
-// ✅ 환경변수 로드 함수
-func LoadEnv() {
-	projectRoot := GetProjectRoot()
-	envLocalPath := filepath.Join(projectRoot, ".env.local")
-	envPath := filepath.Join(projectRoot, ".env")
-	envExamplePath := filepath.Join(projectRoot, ".env.example")
+// ✅ XDG_CONFIG_HOME (없으면 ~/.config)
+func XDGConfigHome() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
 
-	// 우선순위: .env.local > .env > .env.example
-	if _, err := os.Stat(envLocalPath); err == nil {
-		err = godotenv.Load(envLocalPath)
-		if err != nil {
-			log.Printf("⚠️ .env.local 파일 로드 오류: %v", err)
-		} else {
-			log.Println("✅ .env.local 로드 완료")
-			return
-		}
-	}
+// ✅ XDG_DATA_HOME (없으면 ~/.local/share)
+func XDGDataHome() string {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
 
-	if _, err := os.Stat(envPath); err == nil {
-		err = godotenv.Load(envPath)
-		if err != nil {
-			log.Printf("⚠️ .env 파일 로드 오류: %v", err)
-		} else {
-			log.Println("✅ .env 로드 완료")
-			return
-		}
+func xdgDir(env, fallback string) string {
+	// XDG 스펙상 상대 경로는 무시
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
 	}
-
-	if _, err := os.Stat(envExamplePath); err == nil {
-		err = godotenv.Load(envExamplePath)
-		if err != nil {
-			log.Printf("⚠️ .env.example 파일 로드 오류: %v", err)
-		} else {
-			log.Println("✅ .env.example 로드 완료")
-			return
-		}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// 홈을 모르면 임시 디렉토리 아래로 (cwd에 의존하지 않음)
+		home = os.TempDir()
 	}
-
-	// 환경변수 파일이 없는 경우, 기본 파일 생성
-	log.Println("⚠️ 환경변수 파일을 찾을 수 없습니다. 기본 .env 파일을 생성합니다.")
-	createDefaultEnvFile(envPath)
+	return filepath.Join(home, fallback)
 }
 
-// ✅ 기본 환경변수 파일 생성
-func createDefaultEnvFile(filepath string) {
-	content := `# 에디터 환경 설정 파일
-# 이 파일은 에디터의 환경 설정을 관리합니다.
-
-# 저장 파일 경로 설정 (절대 경로 권장)
-SAVE_TXT="/home/rlaaudgjs5638/go_editor/saved.txt"
+// ✅ 앱 설정 디렉토리 ($XDG_CONFIG_HOME/go_editor)
+func ConfigDir() string {
+	return filepath.Join(XDGConfigHome(), AppDirName)
+}
 
-# 기타 설정을 추가할 수 있습니다.
-# THEME="dark"
-# FONT_SIZE="12"
-`
+// ✅ 앱 데이터 디렉토리 ($XDG_DATA_HOME/go_editor)
+func DataDir() string {
+	return filepath.Join(XDGDataHome(), AppDirName)
+}
 
-	err := os.WriteFile(filepath, []byte(content), 0644)
-	if err != nil {
-		log.Printf("⚠️ 기본 .env 파일 생성 실패: %v", err)
+// ✅ 환경변수 로드 함수
+// cwd가 아니라 설정 디렉토리의 .env.local > .env만 읽고, 파일을 새로 만들지 않음
+func LoadEnv() {
+	configDir := ConfigDir()
+	envLocalPath := filepath.Join(configDir, ".env.local")
+	envPath := filepath.Join(configDir, ".env")
+
+	// 우선순위: .env.local > .env
+	for _, path := range []string{envLocalPath, envPath} {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := godotenv.Load(path); err != nil {
+			logger.Warn("환경변수 파일 로드 오류", "file", filepath.Base(path), "err", err)
+			continue
+		}
+		logger.Info("환경변수 파일 로드", "path", path)
 		return
 	}
+}
 
-	err = godotenv.Load(filepath)
-	if err != nil {
-		log.Printf("⚠️ 생성된 .env 파일 로드 실패: %v", err)
-		return
+// ✅ 열 파일 경로 결정
+// 우선순위: 인자로 받은 경로 > SAVE_TXT 환경변수 > $XDG_DATA_HOME/go_editor/saved.txt
+// 항상 절대 경로를 돌려줘서 이후 동작이 cwd에 의존하지 않게 함
+func ResolveOpenPath(arg string) (string, error) {
+	path := arg
+	if path == "" {
+		path = GetSaveTxtPath()
 	}
-
-	log.Println("✅ 기본 .env 파일 생성 및 로드 완료")
+	if path == "" {
+		return "", errors.New("열 파일 경로가 비어 있습니다")
+	}
+	return filepath.Abs(path)
 }
 
 // ✅ SAVE_TXT 환경변수에서 저장 파일 경로를 가져옴
@@ -107,9 +97,8 @@ func GetSaveTxtPath() string {
 	// 환경변수에서 SAVE_TXT 값을 읽어옴 (없으면 기본값 사용)
 	filePath := os.Getenv("SAVE_TXT")
 	if filePath == "" {
-		projectRoot := GetProjectRoot()
-		filePath = filepath.Join(projectRoot, "saved.txt")
-		log.Printf("⚠️ SAVE_TXT 환경변수가 설정되지 않았습니다. 기본값을 사용합니다: %s", filePath)
+		filePath = filepath.Join(DataDir(), defaultFileName)
+		logger.Info("SAVE_TXT가 없어 기본 경로 사용", "path", filePath)
 	}
 	return filePath
 }
@@ -0,0 +1,107 @@
+package handlefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigDirFollowsXDG(t *testing.T) {
+	home := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "xdg")
+	tests := []struct {
+		name string
+		xdg  string
+		want string
+	}{
+		{"절대 경로", abs, filepath.Join(abs, AppDirName)},
+		{"상대 경로는 무시", "relative/config", filepath.Join(home, ".config", AppDirName)},
+		{"없으면 홈 아래", "", filepath.Join(home, ".config", AppDirName)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+			if got := ConfigDir(); got != tt.want {
+				t.Fatalf("ConfigDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOpenPath(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "doc.txt")
+	tests := []struct {
+		name    string
+		arg     string
+		saveTxt string
+		want    string
+	}{
+		{"절대 경로 인자", abs, "", abs},
+		{"상대 경로 인자는 cwd 기준", filepath.Join("sub", "doc.txt"), "", filepath.Join(cwd, "sub", "doc.txt")},
+		{"인자가 SAVE_TXT보다 먼저", abs, "other.txt", abs},
+		{"상대 SAVE_TXT", "", "notes.txt", filepath.Join(cwd, "notes.txt")},
+		{"기본은 데이터 디렉토리", "", "", filepath.Join(data, AppDirName, defaultFileName)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", data)
+			t.Setenv("SAVE_TXT", tt.saveTxt)
+			got, err := ResolveOpenPath(tt.arg)
+			if err != nil || got != tt.want {
+				t.Fatalf("ResolveOpenPath(%q) = %q, %v, want %q", tt.arg, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvCreatesNothing(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"설정 디렉토리 없음", nil, ""},
+		{".env만", map[string]string{".env": "GO_EDITOR_TEST_VAR=env"}, "env"},
+		{".env.local이 먼저", map[string]string{".env": "GO_EDITOR_TEST_VAR=env", ".env.local": "GO_EDITOR_TEST_VAR=local"}, "local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xdg := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+			t.Setenv("GO_EDITOR_TEST_VAR", "")
+			os.Unsetenv("GO_EDITOR_TEST_VAR")
+			for name, content := range tt.files {
+				if err := os.MkdirAll(ConfigDir(), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(ConfigDir(), name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, _ := os.ReadDir(xdg)
+			cwdBefore, _ := os.ReadDir(".")
+
+			LoadEnv()
+
+			if got := os.Getenv("GO_EDITOR_TEST_VAR"); got != tt.want {
+				t.Fatalf("GO_EDITOR_TEST_VAR = %q, want %q", got, tt.want)
+			}
+			after, _ := os.ReadDir(xdg)
+			cwdAfter, _ := os.ReadDir(".")
+			if len(after) != len(before) || len(cwdAfter) != len(cwdBefore) {
+				t.Fatalf("LoadEnv가 파일을 만듦: 설정 %d -> %d, cwd %d -> %d", len(before), len(after), len(cwdBefore), len(cwdAfter))
+			}
+			if len(tt.files) == 0 {
+				if _, err := os.Stat(ConfigDir()); !os.IsNotExist(err) {
+					t.Fatalf("설정 디렉토리가 생김: %v", err)
+				}
+			}
+		})
+	}
+}
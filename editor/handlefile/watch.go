@@ -0,0 +1,84 @@
+package handlefile
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// Watcher: 열린 파일 하나의 외부 변경을 감시
+// git checkout, gofmt처럼 파일을 통째로 갈아끼우는 경우도 잡아야 해서
+// 파일 자체가 아니라 파일이 있는 디렉토리를 감시하고 이름으로 거른다.
+type Watcher struct {
+	path   string
+	dir    string
+	name   string
+	events chan struct{}
+
+	closeOnce sync.Once
+	stop      func() error
+	// 감시 고루틴이 끝나면 닫힘
+	exited chan struct{}
+}
+
+func newWatcher(path string) (*Watcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		path: absPath,
+		dir:  filepath.Dir(absPath),
+		name: filepath.Base(absPath),
+		// 변경 알림은 하나로 합쳐짐 (이미 쌓여있으면 더 넣지 않음)
+		events: make(chan struct{}, 1),
+		exited: make(chan struct{}),
+	}, nil
+}
+
+// WatchFile: path의 변경을 감시하는 Watcher 생성
+func WatchFile(path string) (*Watcher, error) {
+	w, err := newWatcher(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.start(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Events: 파일이 바뀌었을 때 신호가 오는 채널
+func (w *Watcher) Events() <-chan struct{} {
+	return w.events
+}
+
+// Path: 감시 중인 파일의 절대 경로
+func (w *Watcher) Path() string {
+	return w.path
+}
+
+// Close: 감시 종료. 감시 고루틴이 끝날 때까지 기다린 뒤 쌓여 있던 알림도 버림
+// (닫은 뒤에 Events()에서 알림이 오지 않음)
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		if w.stop == nil {
+			return
+		}
+		err = w.stop()
+		<-w.exited
+		select {
+		case <-w.events:
+		default:
+		}
+	})
+	return err
+}
+
+// notify: 이미 대기중인 알림이 있으면 버림
+func (w *Watcher) notify() {
+	select {
+	case w.events <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package handlefile
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// inotify로 볼 이벤트들
+// 저장 완료(CLOSE_WRITE), rename으로 교체(MOVED_TO), 새로 생성/삭제까지 본다.
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM
+
+// start: inotify fd를 열고 디렉토리 감시 시작
+func (w *Watcher) start() error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, w.dir, watchMask); err != nil {
+		syscall.Close(fd)
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// 논블로킹 fd라서 런타임 폴러에 붙음 -> Close하면 Read도 풀림
+	f := os.NewFile(uintptr(fd), "inotify")
+	w.stop = f.Close
+	go w.readLoop(f)
+	return nil
+}
+
+// readLoop: inotify 이벤트를 읽어서 감시 파일 이름과 맞는 것만 알림
+func (w *Watcher) readLoop(f *os.File) {
+	defer close(w.exited)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			return
+		}
+		offset := 0
+		for offset+syscall.SizeofInotifyEvent <= n {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(ev.Len)
+			if nameEnd > n {
+				break
+			}
+			// 이름은 NUL 패딩되어 옴
+			name := string(bytes.TrimRight(buf[nameStart:nameEnd], "\x00"))
+			if name == w.name {
+				w.notify()
+			}
+			offset = nameEnd
+		}
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !linux
+
+package handlefile
+
+import (
+	"os"
+	"time"
+)
+
+// inotify가 없는 플랫폼에선 1초 주기로 수정 시각/크기를 비교
+const pollInterval = time.Second
+
+func (w *Watcher) start() error {
+	done := make(chan struct{})
+	w.stop = func() error {
+		close(done)
+		return nil
+	}
+	go w.pollLoop(done)
+	return nil
+}
+
+func (w *Watcher) pollLoop(done chan struct{}) {
+	defer close(w.exited)
+	var lastMod time.Time
+	var lastSize int64 = -1
+	if info, err := os.Stat(w.path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				if lastSize != -1 {
+					lastSize = -1
+					w.notify()
+				}
+				continue
+			}
+			if !info.ModTime().Equal(lastMod) || info.Size() != lastSize {
+				lastMod, lastSize = info.ModTime(), info.Size()
+				w.notify()
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handlefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitEvent: timeout 안에 알림이 오는지
+func waitEvent(w *Watcher, timeout time.Duration) bool {
+	select {
+	case <-w.Events():
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestWatchFileReportsRewriteAndReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := WatchFile(path)
+	if err != nil {
+		t.Skipf("감시를 시작할 수 없음: %v", err)
+	}
+	defer w.Close()
+
+	// 같은 디렉토리의 다른 파일은 알리지 않음
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if waitEvent(w, 300*time.Millisecond) {
+		t.Fatal("다른 파일 변경을 알림")
+	}
+
+	// 제자리에서 다시 씀
+	if err := os.WriteFile(path, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !waitEvent(w, 3*time.Second) {
+		t.Fatal("다시 쓴 파일을 알리지 않음")
+	}
+	// 쌓인 알림 비움 (한 번 쓰기에 여러 이벤트가 올 수 있음)
+	for waitEvent(w, 300*time.Millisecond) {
+	}
+
+	// 임시 파일에 쓰고 이름을 바꿔서 교체 (git checkout, gofmt 방식)
+	tmp := filepath.Join(dir, ".doc.txt.tmp")
+	if err := os.WriteFile(tmp, []byte("v3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for waitEvent(w, 300*time.Millisecond) {
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if !waitEvent(w, 3*time.Second) {
+		t.Fatal("이름을 바꿔 교체한 파일을 알리지 않음")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("두 번째 Close: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 에디터 구성요소 로거
+// 기본은 아무것도 출력하지 않고, main의 -log-file/-log-level로 출력 대상과 레벨을 정함
+
+// discardHandler: 모든 레벨을 끈 핸들러 (로그 인자도 만들지 않음)
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+// Discard: 아무것도 출력하지 않는 로거 (기본값)
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// New: w에 level 이상을 텍스트 형식으로 출력하는 로거
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// Component: 구성요소 태그를 붙인 로거 (component=syncer 등). l이 nil이면 Discard
+func Component(l *slog.Logger, name string) *slog.Logger {
+	if l == nil {
+		return Discard()
+	}
+	return l.With("component", name)
+}
+
+// ParseLevel: debug, info, warn, error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("알 수 없는 로그 레벨 %q (가능: debug, info, warn, error)", s)
+}
@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestComponentLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := Component(New(&buf, slog.LevelInfo), "syncer")
+	l.Debug("숨김")
+	l.Info("보임", "line", 3)
+	out := buf.String()
+	if strings.Contains(out, "숨김") || !strings.Contains(out, "component=syncer") || !strings.Contains(out, "line=3") {
+		t.Fatalf("로그 출력 = %q", out)
+	}
+
+	if Discard().Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("Discard 로거가 켜져 있음")
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("잘못된 레벨이 통과함")
+	}
+}
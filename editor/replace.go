@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"go_editor/editor/commander"
+	"go_editor/editor/syncer"
+)
+
+// beginReplace: s///c. 매치마다 상태 표시줄에서 y/n/a/q로 물어봄
+func (e *Editor) beginReplace(input commander.SubstituteInput) {
+	rs, err := e.syncProtocol.BeginReplace(input)
+	if err != nil {
+		e.logger.Warn("치환 실패", "err", err)
+		e.syncProtocol.SetMessage("Substitute failed: %s", syncer.StatusError(err))
+		return
+	}
+	e.replaceSession = rs
+	if rs.Done() {
+		e.finishReplace()
+	}
+}
+
+// answerReplace: 확인 치환중의 키 입력
+// y: 바꿈, n: 건너뜀, a: 남은 것 모두 바꿈, q/ESC: 지금까지 고른 것만 바꾸고 끝
+func (e *Editor) answerReplace(cmd commander.Command) {
+	rs := e.replaceSession
+	if cmd.Code == commander.CmdExit {
+		e.finishReplace()
+		return
+	}
+	charInput, ok := cmd.Input.(commander.CharInput)
+	if !ok || cmd.Code != commander.CmdInsert {
+		return
+	}
+	switch charInput.Char {
+	case 'y', 'Y':
+		rs.Answer(true)
+	case 'n', 'N':
+		rs.Answer(false)
+	case 'a', 'A':
+		rs.AcceptAll()
+	case 'q', 'Q':
+		e.finishReplace()
+		return
+	default:
+		return
+	}
+	if rs.Done() {
+		e.finishReplace()
+	}
+}
+
+// finishReplace: 수락한 치환을 한 번에 적용 (되돌리기 한 번으로 전부 취소됨)
+func (e *Editor) finishReplace() {
+	count := e.replaceSession.Finish()
+	e.replaceSession = nil
+	e.syncProtocol.SetMessage("%d substitutions", count)
+}
@@ -1,6 +1,9 @@
 package screener
 
 import (
+	"go_editor/editor/logging"
+	"log/slog"
+
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil"
 )
@@ -10,8 +13,13 @@ import (
 //	그걸 렌더링하는 역할 수행
 const LineHeight = 16 // 한 줄 높이 16픽셀
 type Screener struct {
-	width  int
-	height int
+	width      int
+	height     int
+	lineHeight int
+	bg         uint32
+
+	// 맨 아래 상태 표시줄 높이 (문서 라인은 그 위까지만 그림)
+	statusHeight int
 
 	screenBuffer []uint32
 
@@ -19,6 +27,8 @@ type Screener struct {
 	window xproto.Window
 	gc     xproto.Gcontext
 	depth  byte
+
+	logger *slog.Logger
 }
 
 // NewScreener: XGBUtil을 기반으로 Screener 초기화
@@ -44,8 +54,8 @@ func NewScreener(xu *xgbutil.XUtil, width, height int, fg, bg uint32) (*Screener
 		defaultScreen.RootVisual,
 		xproto.CwBackPixel|xproto.CwEventMask,
 		[]uint32{
-			defaultScreen.WhitePixel,
-			xproto.EventMaskExposure | xproto.EventMaskKeyPress,
+			bg,
+			xproto.EventMaskExposure | xproto.EventMaskKeyPress | xproto.EventMaskButtonPress,
 		},
 	)
 
@@ -76,12 +86,15 @@ func NewScreener(xu *xgbutil.XUtil, width, height int, fg, bg uint32) (*Screener
 	s := &Screener{
 		width:        width,
 		height:       height,
+		lineHeight:   LineHeight,
+		bg:           bg,
 		screenBuffer: make([]uint32, width*height),
 
 		xu:     xu,
 		window: windowId,
 		gc:     gcId,
 		depth:  defaultScreen.RootDepth,
+		logger: logging.Discard(),
 	}
 
 	return s, nil
@@ -172,29 +185,120 @@ func NewScreener(xu *xgbutil.XUtil, width, height int, fg, bg uint32) (*Screener
 // TODO 여기서부턴 스크리너 고유영역
 // TODO XGB나 XU다루는 순간은 스크리너에서 처리
 
+// SetLogger: 로거 교체
+func (s *Screener) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Discard()
+	}
+	s.logger = l
+}
+
+// SetLineHeight: 라인버퍼 한 줄의 픽셀 높이 (SyncProtocol과 맞춰야 함)
+func (s *Screener) SetLineHeight(lineHeight int) {
+	s.lineHeight = lineHeight
+}
+
+// SetStatusHeight: 창 아래쪽에 상태 표시줄 자리를 남김
+func (s *Screener) SetStatusHeight(height int) {
+	s.statusHeight = min(max(height, 0), s.height)
+}
+
+// DocumentHeight: 문서 라인들이 그려지는 영역의 높이
+func (s *Screener) DocumentHeight() int {
+	return s.height - s.statusHeight
+}
+
+// SetBackground: 창 배경색 (라인버퍼가 덮지 않는 영역에 보임)
+func (s *Screener) SetBackground(bg uint32) {
+	s.bg = bg
+	xproto.ChangeWindowAttributes(s.xu.Conn(), s.window, xproto.CwBackPixel, []uint32{bg})
+	xproto.ClearArea(s.xu.Conn(), false, s.window, 0, 0, 0, 0)
+}
+
+// SetTitle: 창 제목 변경
+// WM_NAME(STRING)은 한글이 깨지므로 _NET_WM_NAME(UTF8_STRING)도 같이 설정
+func (s *Screener) SetTitle(title string) {
+	data := []byte(title)
+	xproto.ChangeProperty(
+		s.xu.Conn(),
+		xproto.PropModeReplace,
+		s.window,
+		xproto.AtomWmName,
+		xproto.AtomString,
+		8,
+		uint32(len(data)),
+		data,
+	)
+
+	netWmName, err := s.internAtom("_NET_WM_NAME")
+	if err != nil {
+		s.logger.Warn("아톰 조회 실패", "atom", "_NET_WM_NAME", "err", err)
+		return
+	}
+	utf8String, err := s.internAtom("UTF8_STRING")
+	if err != nil {
+		s.logger.Warn("아톰 조회 실패", "atom", "UTF8_STRING", "err", err)
+		return
+	}
+	xproto.ChangeProperty(
+		s.xu.Conn(),
+		xproto.PropModeReplace,
+		s.window,
+		netWmName,
+		utf8String,
+		8,
+		uint32(len(data)),
+		data,
+	)
+}
+
+// internAtom: 이름으로 X 아톰 조회
+func (s *Screener) internAtom(name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(s.xu.Conn(), false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
 // FlushBuffer: line기준 => 전체 스크린 버퍼 => X 서버
-func (s *Screener) FlushBuffer(screenLines [][]uint32) {
+// statusLine은 맨 아래 상태 표시줄 픽셀 (nil이면 배경색)
+func (s *Screener) FlushBuffer(screenLines [][]uint32, statusLine []uint32) {
+	docEnd := s.DocumentHeight() * s.width
 
 	// (1) line들을 하나의 screenBuffer로 합침
 	// lineIndex=0 => y=0..15
 	// lineIndex=1 => y=16..31
 	for lineIndex := 0; lineIndex < len(screenLines); lineIndex++ {
 		linePixels := screenLines[lineIndex]
-		// 한 줄(16행 * width열)
-		for row := 0; row < LineHeight; row++ {
+		// 한 줄(lineHeight행 * width열)
+		for row := 0; row < s.lineHeight; row++ {
 			for col := 0; col < s.width; col++ {
 				pix := linePixels[row*s.width+col]
-				y := lineIndex*LineHeight + row
+				y := lineIndex*s.lineHeight + row
 				x := col
-				if y*s.width+x >= len(s.screenBuffer) {
+				if y*s.width+x >= docEnd {
 					// 버퍼 오버플로우 방지
-					// 윈도우 값을 넘어가는 라인이 나오면 그리기를 종료
+					// 문서 영역을 넘어가는 라인이 나오면 그리기를 종료
 					break
 				}
 				s.screenBuffer[y*s.width+x] = pix
 			}
 		}
 	}
+	// 라인이 채우지 못한 문서 영역 아래쪽은 배경색 (확대/축소 후 남는 자리)
+	for i := min(len(screenLines)*s.lineHeight*s.width, docEnd); i < docEnd; i++ {
+		s.screenBuffer[i] = s.bg
+	}
+	// 상태 표시줄
+	status := s.screenBuffer[docEnd:]
+	for i := range status {
+		if i < len(statusLine) {
+			status[i] = statusLine[i]
+		} else {
+			status[i] = s.bg
+		}
+	}
 
 	// (2) 기존 chunkHeight=64로 전송
 	chunkHeight := 64
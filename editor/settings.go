@@ -0,0 +1,195 @@
+package editor
+
+import (
+	"go_editor/editor/commander"
+	"go_editor/editor/config"
+	"go_editor/editor/syncer"
+	"go_editor/editor/theme"
+	"log/slog"
+	"time"
+)
+
+// applyConfig: 설정 값을 실행중인 에디터에 반영
+// 창 크기와 줄 높이는 라인버퍼/윈도우를 새로 만들어야 해서 재시작 후 적용됨
+func (e *Editor) applyConfig(cfg *config.Config) {
+	prev := e.config
+	e.config = cfg
+
+	if prev != nil {
+		if prev.Window != cfg.Window || prev.LineHeight != cfg.LineHeight {
+			e.logger.Info("window, line_height 변경은 재시작 후 적용됨")
+		}
+		if prev.FPS != cfg.FPS {
+			e.fpsTicker.Reset(time.Second / time.Duration(cfg.FPS))
+		}
+	}
+	// 실행중에 바꾼 테마는 설정의 테마/색이 바뀌었을 때만 덮어씀
+	if prev == nil || prev.Theme != cfg.Theme || !sameColors(prev.Colors, cfg.Colors) {
+		e.applyTheme(resolveTheme(e.logger, e.themes, cfg))
+	}
+
+	e.syncProtocol.SetCursorShape(cursorShape(cfg.Cursor.Shape))
+	e.syncProtocol.SetTabWidth(cfg.TabWidth)
+	if prev == nil || prev.LineNumbers != cfg.LineNumbers {
+		e.syncProtocol.SetGutterMode(gutterMode(cfg.LineNumbers))
+	}
+	e.commander.SetKeymap(cfg.Keymap())
+
+	if cfg.Cursor.BlinkRateMs > 0 {
+		e.blinkTicker.Reset(time.Duration(cfg.Cursor.BlinkRateMs) * time.Millisecond)
+	} else {
+		// 깜빡이지 않는 커서
+		e.blinkTicker.Stop()
+		e.syncProtocol.CursorDrawOn()
+	}
+}
+
+// reloadConfig: 설정 파일이 바뀌었을 때 다시 읽기
+// 검증에 실패하면 기존 설정을 그대로 유지
+func (e *Editor) reloadConfig() {
+	cfg, err := config.Load(e.configSources)
+	if err != nil {
+		e.logger.Warn("설정을 다시 읽지 못해 기존 설정 유지", "err", err)
+		e.syncProtocol.SetMessage("Config error, keeping previous settings (see log)")
+		return
+	}
+	e.applyConfig(cfg)
+	e.logger.Info("설정을 다시 불러옴")
+	e.syncProtocol.SetMessage("Config reloaded")
+}
+
+// resolveTheme: 설정의 테마 이름에 색 덮어쓰기를 적용한 테마
+func resolveTheme(logger *slog.Logger, themes *theme.Registry, cfg *config.Config) *theme.Theme {
+	base, ok := themes.Get(cfg.Theme)
+	if !ok {
+		logger.Warn("테마가 없어 light 테마 사용", "theme", cfg.Theme, "available", themes.Names())
+		base = theme.Light
+	}
+	t := base.Clone()
+	if cfg.Colors.Foreground != nil {
+		t.Foreground = *cfg.Colors.Foreground
+	}
+	if cfg.Colors.Background != nil {
+		t.Background = *cfg.Colors.Background
+	}
+	if cfg.Colors.Cursor != nil {
+		t.Cursor = *cfg.Colors.Cursor
+	}
+	return t
+}
+
+func sameColors(a, b config.Colors) bool {
+	eq := func(x, y *config.Color) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return eq(a.Foreground, b.Foreground) && eq(a.Background, b.Background) && eq(a.Cursor, b.Cursor)
+}
+
+// applyTheme: 문서 라인버퍼와 창 배경에 테마 적용
+func (e *Editor) applyTheme(t *theme.Theme) {
+	e.syncProtocol.SetTheme(t)
+	e.screener.SetBackground(uint32(t.Background))
+}
+
+// processEditorCommand: 테마 전환처럼 에디터 단에서 처리할 명령
+// 처리했으면 true
+func (e *Editor) processEditorCommand(cmd commander.Command) bool {
+	switch cmd.Code {
+	case commander.CmdNextTheme:
+		current := ""
+		if t := e.syncProtocol.Theme(); t != nil {
+			current = t.Name
+		}
+		next := e.themes.Next(current)
+		e.applyTheme(next)
+		e.logger.Info("테마 변경", "theme", next.Name)
+		e.syncProtocol.SetMessage("Theme: %s", next.Name)
+		return true
+	case commander.CmdSetTheme:
+		input, ok := cmd.Input.(commander.ThemeInput)
+		if !ok {
+			return true
+		}
+		t, found := e.themes.Get(input.Name)
+		if !found {
+			e.logger.Warn("테마 없음", "theme", input.Name, "available", e.themes.Names())
+			e.syncProtocol.SetMessage("Unknown theme: %s", input.Name)
+			return true
+		}
+		e.applyTheme(t)
+		return true
+	case commander.CmdFind:
+		e.openFindPrompt()
+		return true
+	case commander.CmdFindNext, commander.CmdFindPrev:
+		e.findNext(cmd.Code == commander.CmdFindNext)
+		return true
+	case commander.CmdCommandLine:
+		if e.syncProtocol.HasSelection() {
+			e.minibuffer.OpenWith(commander.SelectionRangePrefix)
+		} else {
+			e.minibuffer.Open()
+		}
+		return true
+	case commander.CmdSubstitute:
+		input, ok := cmd.Input.(commander.SubstituteInput)
+		if !ok || !input.Confirm {
+			// 확인 없는 치환은 문서 명령으로 처리
+			return false
+		}
+		e.beginReplace(input)
+		return true
+	case commander.CmdOpen:
+		if input, ok := cmd.Input.(commander.PathInput); ok {
+			e.openFile(input.Path)
+		}
+		return true
+	case commander.CmdSet:
+		if input, ok := cmd.Input.(commander.SettingInput); ok {
+			e.applySetting(input.Name, input.Value)
+		}
+		return true
+	case commander.CmdToggleLineNumbers:
+		// off -> absolute -> relative -> off
+		e.syncProtocol.SetGutterMode((e.syncProtocol.GutterMode() + 1) % (syncer.GutterRelative + 1))
+		return true
+	case commander.CmdZoomIn:
+		e.setZoom(e.syncProtocol.Scale() + 1)
+		return true
+	case commander.CmdZoomOut:
+		e.setZoom(e.syncProtocol.Scale() - 1)
+		return true
+	case commander.CmdZoomReset:
+		e.setZoom(syncer.MinScale)
+		return true
+	}
+	return false
+}
+
+// setZoom: 글리프 배율을 바꾸고 화면 줄 높이도 맞춤
+func (e *Editor) setZoom(scale int) {
+	e.syncProtocol.SetScale(scale)
+	e.screener.SetLineHeight(e.syncProtocol.LineHeight)
+	e.logger.Debug("확대", "scale", e.syncProtocol.Scale())
+	e.syncProtocol.SetMessage("Zoom %dx", e.syncProtocol.Scale())
+}
+
+func cursorShape(shape string) syncer.CursorShape {
+	switch shape {
+	case config.CursorBlock:
+		return syncer.CursorBlock
+	case config.CursorUnderline:
+		return syncer.CursorUnderline
+	}
+	return syncer.CursorBar
+}
+
+func gutterMode(mode string) syncer.GutterMode {
+	switch mode {
+	case config.LineNumbersAbsolute:
+		return syncer.GutterAbsolute
+	case config.LineNumbersRelative:
+		return syncer.GutterRelative
+	}
+	return syncer.GutterOff
+}
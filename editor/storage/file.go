@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+	"go_editor/editor/handlefile"
+	"os"
+	"path/filepath"
+)
+
+// FileStorage: 파일 시스템의 파일 하나
+type FileStorage struct {
+	path string
+}
+
+func NewFileStorage(path string) *FileStorage {
+	return &FileStorage{path: path}
+}
+
+// Path: 파일 경로
+func (fs *FileStorage) Path() string {
+	return fs.path
+}
+
+func (fs *FileStorage) Load() ([]byte, error) {
+	return os.ReadFile(fs.path)
+}
+
+func (fs *FileStorage) Save(data []byte) error {
+	// 저장 디렉토리가 존재하는지 확인하고 없으면 생성
+	dir := filepath.Dir(fs.path)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("디렉토리 생성 실패: %v", err)
+		}
+	}
+	return os.WriteFile(fs.path, data, 0644)
+}
+
+func (fs *FileStorage) Stat() (Info, error) {
+	info, err := os.Stat(fs.path)
+	if os.IsNotExist(err) {
+		return Info{Name: fs.path}, nil
+	}
+	if err != nil {
+		return Info{Name: fs.path}, err
+	}
+	return Info{
+		Name:     fs.path,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Exists:   true,
+		ReadOnly: info.Mode().Perm()&0200 == 0,
+	}, nil
+}
+
+func (fs *FileStorage) Watch() (Watcher, error) {
+	w, err := handlefile.WatchFile(fs.path)
+	if err != nil {
+		// nil 포인터가 인터페이스에 담겨 나가지 않게 함
+		return nil, err
+	}
+	return w, nil
+}
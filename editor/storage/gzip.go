@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// GzipStorage: 다른 Storage를 감싸서 gzip 압축을 투명하게 처리
+type GzipStorage struct {
+	inner Storage
+}
+
+func NewGzipStorage(inner Storage) *GzipStorage {
+	return &GzipStorage{inner: inner}
+}
+
+func (gs *GzipStorage) Load() ([]byte, error) {
+	compressed, err := gs.inner.Load()
+	if err != nil {
+		return nil, err
+	}
+	// 빈 파일은 빈 문서로 취급
+	if len(compressed) == 0 {
+		return []byte{}, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func (gs *GzipStorage) Save(data []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return gs.inner.Save(buf.Bytes())
+}
+
+func (gs *GzipStorage) Stat() (Info, error) {
+	return gs.inner.Stat()
+}
+
+func (gs *GzipStorage) Watch() (Watcher, error) {
+	return gs.inner.Watch()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package storage
+
+import "os"
+
+// mapFile: 매핑을 지원하지 않는 플랫폼에서는 그냥 읽음
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
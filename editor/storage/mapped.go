@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Mapper: 내용을 복사하지 않고 읽기 전용 메모리 매핑으로 줄 수 있는 저장소
+type Mapper interface {
+	// Map: 내용의 읽기 전용 매핑과 해제 함수. 해제한 뒤에는 data를 쓰면 안 됨
+	Map() (data []byte, unmap func() error, err error)
+}
+
+// MappedFileStorage: 파일을 메모리 매핑으로 읽는 저장소 (큰 파일을 메모리에 복사하지 않음)
+// 매핑된 파일을 제자리에서 덮어쓰면 매핑된 내용도 바뀌므로 Save는 임시 파일에 쓰고 이름을 바꿈
+// 다른 프로그램이 파일을 제자리에서 줄이면 매핑을 읽다가 프로세스가 죽을 수 있음 (SIGBUS)
+type MappedFileStorage struct {
+	*FileStorage
+}
+
+func NewMappedFileStorage(path string) *MappedFileStorage {
+	return &MappedFileStorage{FileStorage: NewFileStorage(path)}
+}
+
+func (ms *MappedFileStorage) Map() ([]byte, func() error, error) {
+	return mapFile(ms.path)
+}
+
+// Save: 같은 디렉토리의 임시 파일에 쓰고 원래 이름으로 바꿈 (권한은 원래 파일을 따름)
+func (ms *MappedFileStorage) Save(data []byte) error {
+	dir := filepath.Dir(ms.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(ms.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(ms.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 이름을 바꾼 뒤에는 아무 일도 안 함
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ms.path)
+}
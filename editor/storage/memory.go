@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// MemoryStorage: 메모리상의 내용. 테스트나 임베딩용 (디스크를 건드리지 않음)
+type MemoryStorage struct {
+	mu       sync.Mutex
+	name     string
+	data     []byte
+	exists   bool
+	modTime  time.Time
+	watchers map[*memoryWatcher]struct{}
+}
+
+// NewMemoryStorage: data가 nil이면 아직 저장된 적 없는 상태로 시작
+func NewMemoryStorage(data []byte) *MemoryStorage {
+	ms := &MemoryStorage{
+		name:     "memory",
+		watchers: map[*memoryWatcher]struct{}{},
+	}
+	if data != nil {
+		ms.data = append([]byte(nil), data...)
+		ms.exists = true
+		ms.modTime = time.Now()
+	}
+	return ms
+}
+
+func (ms *MemoryStorage) Load() ([]byte, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if !ms.exists {
+		return nil, &fs.PathError{Op: "load", Path: ms.name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), ms.data...), nil
+}
+
+// Save: 내용 교체 후 Watch 중인 쪽에 알림
+// 외부 도구가 파일을 바꾸는 상황도 Save로 흉내낼 수 있음
+func (ms *MemoryStorage) Save(data []byte) error {
+	ms.mu.Lock()
+	ms.data = append([]byte(nil), data...)
+	ms.exists = true
+	ms.modTime = time.Now()
+	watchers := make([]*memoryWatcher, 0, len(ms.watchers))
+	for w := range ms.watchers {
+		watchers = append(watchers, w)
+	}
+	ms.mu.Unlock()
+
+	for _, w := range watchers {
+		w.notify()
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) Stat() (Info, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return Info{
+		Name:    ms.name,
+		Size:    int64(len(ms.data)),
+		ModTime: ms.modTime,
+		Exists:  ms.exists,
+	}, nil
+}
+
+func (ms *MemoryStorage) Watch() (Watcher, error) {
+	w := &memoryWatcher{
+		owner:  ms,
+		events: make(chan struct{}, 1),
+	}
+	ms.mu.Lock()
+	ms.watchers[w] = struct{}{}
+	ms.mu.Unlock()
+	return w, nil
+}
+
+type memoryWatcher struct {
+	owner  *MemoryStorage
+	events chan struct{}
+}
+
+func (w *memoryWatcher) Events() <-chan struct{} {
+	return w.events
+}
+
+func (w *memoryWatcher) Close() error {
+	w.owner.mu.Lock()
+	delete(w.owner.watchers, w)
+	w.owner.mu.Unlock()
+	return nil
+}
+
+func (w *memoryWatcher) notify() {
+	select {
+	case w.events <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,28 @@
+package storage
+
+// ReadOnlyStorage: 다른 Storage를 감싸서 Save를 막음
+type ReadOnlyStorage struct {
+	inner Storage
+}
+
+func NewReadOnlyStorage(inner Storage) *ReadOnlyStorage {
+	return &ReadOnlyStorage{inner: inner}
+}
+
+func (rs *ReadOnlyStorage) Load() ([]byte, error) {
+	return rs.inner.Load()
+}
+
+func (rs *ReadOnlyStorage) Save(data []byte) error {
+	return ErrReadOnly
+}
+
+func (rs *ReadOnlyStorage) Stat() (Info, error) {
+	info, err := rs.inner.Stat()
+	info.ReadOnly = true
+	return info, err
+}
+
+func (rs *ReadOnlyStorage) Watch() (Watcher, error) {
+	return rs.inner.Watch()
+}
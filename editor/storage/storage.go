@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Storage: 문서 내용을 어디서 읽고 어디에 쓸지 추상화
+// SyncProtocol은 os나 경로를 직접 다루지 않고 이 인터페이스만 사용한다.
+type Storage interface {
+	// Load: 저장된 내용 전체. 없으면 fs.ErrNotExist를 감싼 에러
+	Load() ([]byte, error)
+	// Save: 내용 전체를 교체
+	Save(data []byte) error
+	// Stat: 이름, 크기, 수정 시각 등
+	Stat() (Info, error)
+	// Watch: 외부 변경 알림
+	Watch() (Watcher, error)
+}
+
+// Info: Stat 결과
+type Info struct {
+	Name     string // 표시용 이름 (파일이면 경로)
+	Size     int64  // 저장소에 실제로 저장된 크기 (gzip이면 압축된 크기)
+	ModTime  time.Time
+	Exists   bool
+	ReadOnly bool
+}
+
+// Watcher: 변경 알림 채널과 종료
+// handlefile.Watcher가 그대로 이 인터페이스를 만족함
+type Watcher interface {
+	Events() <-chan struct{}
+	Close() error
+}
+
+// ErrReadOnly: 읽기 전용 저장소에 Save한 경우
+var ErrReadOnly = errors.New("storage: 읽기 전용입니다")
+
+// Open에서 일반 파일을 메모리 매핑으로 열지 (SetMapFiles)
+var mapFiles bool
+
+// SetMapFiles: 이후 Open하는 일반 파일을 MappedFileStorage로 열지 설정
+func SetMapFiles(on bool) {
+	mapFiles = on
+}
+
+// Open: 경로에 맞는 파일 저장소 생성
+// .gz 파일은 압축을 투명하게 풀고 다시 압축해서 저장한다.
+func Open(path string) Storage {
+	if strings.HasSuffix(path, ".gz") {
+		return NewGzipStorage(NewFileStorage(path))
+	}
+	if mapFiles {
+		return NewMappedFileStorage(path)
+	}
+	return NewFileStorage(path)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ms := NewMemoryStorage(nil)
+	if _, err := ms.Load(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("빈 저장소 Load: ErrNotExist 기대, got %v", err)
+	}
+
+	w, _ := ms.Watch()
+	defer w.Close()
+	if err := ms.Save([]byte("hello\nworld")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Events():
+	default:
+		t.Fatal("Save 후 변경 알림이 없음")
+	}
+
+	data, err := ms.Load()
+	if err != nil || string(data) != "hello\nworld" {
+		t.Fatalf("Load = %q, %v", data, err)
+	}
+	info, _ := ms.Stat()
+	if !info.Exists || info.Size != int64(len(data)) {
+		t.Fatalf("Stat = %+v", info)
+	}
+}
+
+func TestGzipStorage(t *testing.T) {
+	inner := NewMemoryStorage(nil)
+	gs := NewGzipStorage(inner)
+	text := "압축 테스트\nline 2"
+	if err := gs.Save([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := inner.Load()
+	if string(raw) == text {
+		t.Fatal("내부 저장소에 압축되지 않은 내용이 저장됨")
+	}
+	data, err := gs.Load()
+	if err != nil || string(data) != text {
+		t.Fatalf("Load = %q, %v", data, err)
+	}
+}
+
+func TestReadOnlyStorage(t *testing.T) {
+	rs := NewReadOnlyStorage(NewMemoryStorage([]byte("keep")))
+	if err := rs.Save([]byte("changed")); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Save: ErrReadOnly 기대, got %v", err)
+	}
+	data, _ := rs.Load()
+	if string(data) != "keep" {
+		t.Fatalf("Load = %q", data)
+	}
+	if info, _ := rs.Stat(); !info.ReadOnly {
+		t.Fatal("Stat.ReadOnly가 false")
+	}
+}
+
+func TestMappedFileStorageSaveKeepsMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(path, []byte("first\nsecond"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ms := NewMappedFileStorage(path)
+	data, unmap, err := ms.Map()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unmap()
+
+	// 임시 파일 + 이름 바꾸기라서 매핑된 옛 내용은 그대로
+	if err := ms.Save([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond" {
+		t.Fatalf("저장 후 매핑 = %q", data)
+	}
+	saved, err := ms.Load()
+	info, _ := os.Stat(path)
+	if err != nil || string(saved) != "x" || info.Mode().Perm() != 0600 {
+		t.Fatalf("저장된 내용 = %q (%v), 권한 %v", saved, err, info.Mode().Perm())
+	}
+}
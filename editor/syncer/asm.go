@@ -0,0 +1,268 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// op 시퀀스의 텍스트(어셈블리) 형식
+//
+//	GROUP slice @L3; TEXT hold; SYNC sliced @L3; CURSOR downLeftStart
+//
+// 명령은 ';' 또는 줄바꿈으로 구분하고 '#'부터 줄 끝까지는 주석.
+// 명령 하나는 "종류 코드 [피연산자...]"이고 피연산자는
+// @L<n> (대상 노드, 1부터 시작하는 라인 번호), 'c' (글자), "text" (문자열). 따옴표는 Go 문법
+
+// opTrace: -trace-ops로 지정한 파일. 실행하는 프로그램마다 한 줄씩 기록
+var opTrace io.Writer
+
+// SetOpTrace: 실행하는 op 프로그램을 w에 기록 (nil이면 끔)
+func SetOpTrace(w io.Writer) {
+	opTrace = w
+}
+
+// traceProgram: 실행 전에 기록해야 대상 라인 번호가 실행 전 기준
+func (sp *SyncProtocol) traceProgram(ops *OpSequences) {
+	if opTrace == nil || ops.Len() == 0 {
+		return
+	}
+	fmt.Fprintln(opTrace, ops.Format(sp))
+}
+
+// Format: 시퀀스를 한 줄 텍스트로
+func (ops *OpSequences) Format(sp *SyncProtocol) string {
+	parts := make([]string, len(ops.ops))
+	for i, op := range ops.ops {
+		parts[i] = sp.formatOp(op)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatOp: 명령 하나, 대상 노드는 현재 라인 번호로
+func (sp *SyncProtocol) formatOp(op Op) string {
+	info := op.Info()
+	target := ""
+	if info.Target != nil {
+		if order := sp.doc.data.findOrder(info.Target); order >= 0 {
+			target = fmt.Sprintf("@L%d", order+1)
+		} else {
+			target = "@L?" // 리스트에서 빠진 노드
+		}
+	}
+	return formatInstruction(info, target)
+}
+
+func formatInstruction(info OpInfo, target string) string {
+	k, ok := opKinds[info.Kind]
+	if !ok {
+		return fmt.Sprintf("KIND%d %d", info.Kind, info.Code)
+	}
+	parts := []string{k.mnemonic, codeName(k.codeNames, info.Code)}
+	if target != "" {
+		parts = append(parts, target)
+	}
+	if hasCharOperand(info) {
+		parts = append(parts, strconv.QuoteRune(info.Char))
+	}
+	if info.Text != "" {
+		parts = append(parts, strconv.Quote(info.Text))
+	}
+	return strings.Join(parts, " ")
+}
+
+// hasCharOperand: 기본 종류는 글자 삽입만 글자를 씀 (나머지는 자리만 채운 ' ')
+func hasCharOperand(info OpInfo) bool {
+	switch info.Kind {
+	case OpKindNodeText:
+		return info.Code == int(OpInsertRune)
+	case OpKindNodeGroup, OpKindSync, OpKindCursor:
+		return false
+	}
+	return info.Char != 0
+}
+
+// ParseOps: 텍스트를 시퀀스로. 대상 라인은 sp의 현재 문서 기준
+func ParseOps(sp *SyncProtocol, src string) (*OpSequences, error) {
+	ops := NewOpSequences()
+	for i, inst := range splitInstructions(src) {
+		op, err := sp.parseInstruction(inst)
+		if err != nil {
+			return nil, fmt.Errorf("%d번째 명령 %q: %v", i+1, inst, err)
+		}
+		ops.Append(op)
+	}
+	return ops, nil
+}
+
+// splitInstructions: 따옴표 밖의 ';', 줄바꿈으로 나누고 주석 제거
+func splitInstructions(src string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote rune
+	escaped, comment := false, false
+	flush := func() {
+		if inst := strings.TrimSpace(cur.String()); inst != "" {
+			out = append(out, inst)
+		}
+		cur.Reset()
+	}
+	for _, r := range src {
+		switch {
+		case comment:
+			if r == '\n' {
+				comment = false
+				flush()
+			}
+			continue
+		case quote != 0:
+			cur.WriteRune(r)
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '\'', '"':
+			quote = r
+			cur.WriteRune(r)
+		case '#':
+			comment = true
+		case ';', '\n':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
+
+func (sp *SyncProtocol) parseInstruction(inst string) (Op, error) {
+	fields := strings.Fields(inst)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("종류와 코드가 필요함")
+	}
+	kind, k, ok := kindByMnemonic(fields[0])
+	if !ok {
+		return nil, fmt.Errorf("알 수 없는 종류 %q", fields[0])
+	}
+	code := -1
+	for i, name := range k.codeNames {
+		if name == fields[1] {
+			code = i
+			break
+		}
+	}
+	if code < 0 {
+		return nil, fmt.Errorf("%s: 알 수 없는 코드 %q (가능: %s)", k.mnemonic, fields[1], strings.Join(k.codeNames, ", "))
+	}
+	info := OpInfo{Kind: kind, Code: code}
+
+	// 코드 뒤의 피연산자 (따옴표 안에 공백이 있을 수 있으므로 직접 자름)
+	afterKind := strings.Index(inst, fields[0]) + len(fields[0])
+	rest := inst[afterKind:]
+	rest = strings.TrimSpace(rest[strings.Index(rest, fields[1])+len(fields[1]):])
+	for rest != "" {
+		var operand string
+		switch rest[0] {
+		case '\'', '"':
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("따옴표 오류: %s", rest)
+			}
+			operand = quoted
+		default:
+			operand, _, _ = strings.Cut(rest, " ")
+		}
+		rest = strings.TrimSpace(rest[len(operand):])
+		if err := sp.parseOperand(&info, operand); err != nil {
+			return nil, err
+		}
+	}
+	if k.decode == nil {
+		return nil, fmt.Errorf("%s는 파싱할 수 없는 종류", k.mnemonic)
+	}
+	return k.decode(info)
+}
+
+func (sp *SyncProtocol) parseOperand(info *OpInfo, operand string) error {
+	switch {
+	case strings.HasPrefix(operand, "@L"):
+		n, err := strconv.Atoi(operand[2:])
+		if err != nil || n < 1 {
+			return fmt.Errorf("잘못된 라인 %q", operand)
+		}
+		node, found := sp.doc.data.findNode(uint(n - 1))
+		if !found || sp.doc.data.findOrder(node) != n-1 {
+			return fmt.Errorf("라인 %d 없음", n)
+		}
+		info.Target = node
+	case operand[0] == '\'':
+		s, err := strconv.Unquote(operand)
+		if err != nil || utf8.RuneCountInString(s) != 1 {
+			return fmt.Errorf("잘못된 글자 %s", operand)
+		}
+		info.Char, _ = utf8.DecodeRuneInString(s)
+	case operand[0] == '"':
+		s, err := strconv.Unquote(operand)
+		if err != nil {
+			return fmt.Errorf("잘못된 문자열 %s", operand)
+		}
+		info.Text = s
+	default:
+		return fmt.Errorf("알 수 없는 피연산자 %q", operand)
+	}
+	return nil
+}
+
+func kindByMnemonic(mnemonic string) (OpKind, opKindInfo, bool) {
+	for kind, k := range opKinds {
+		if k.mnemonic == mnemonic {
+			return kind, k, true
+		}
+	}
+	return 0, opKindInfo{}, false
+}
+
+// 기본 종류 디코더: 대상이 필요한 종류는 @L이 없으면 오류
+
+func decodeNodeGroup(info OpInfo) (Op, error) {
+	if info.Target == nil {
+		return nil, fmt.Errorf("GROUP에는 대상 라인(@L)이 필요함")
+	}
+	return NewOpNodeGroup(NodeGroupOpCode(info.Code), info.Target), nil
+}
+
+func decodeNodeText(info OpInfo) (Op, error) {
+	switch NodeTextOpCode(info.Code) {
+	case OpTypeText:
+		return NewOpTypeText(info.Text), nil
+	case OpInsertRune:
+		if info.Char == 0 {
+			return nil, fmt.Errorf("TEXT insert에는 글자가 필요함")
+		}
+	}
+	char := info.Char
+	if char == 0 {
+		char = ' '
+	}
+	return NewOpNodeText(NodeTextOpCode(info.Code), char), nil
+}
+
+func decodeSync(info OpInfo) (Op, error) {
+	if info.Target == nil {
+		return nil, fmt.Errorf("SYNC에는 대상 라인(@L)이 필요함")
+	}
+	return NewOpNodeSync(SyncOpCode(info.Code), info.Target), nil
+}
+
+func decodeCursor(info OpInfo) (Op, error) {
+	return NewOpNodeCursor(CursorOpCode(info.Code)), nil
+}
@@ -0,0 +1,105 @@
+package syncer
+
+// ChangeEvent: 문서 변경 하나 (op 인터프리터가 실행하면서 만듦)
+//   - NodeInserted: Line에 빈 라인이 생김. After = [""]
+//   - NodeDeleted: Line이 빠짐. Before = [지운 라인]
+//   - NodeSliced: Line이 둘로 나뉨. Before = [원래 라인], After = [앞, 뒤]
+//   - NodeMerged: Line과 다음 라인이 합쳐짐. Before = [앞, 뒤], After = [합친 라인]
+//   - NodeModified: Line의 텍스트가 바뀜. Before/After = [바뀌기 전], [바뀐 뒤]
+//   - DocumentReplaced: 문서 전체가 바뀜 (다시 불러오기, 되돌리기). Before/After = 전체 라인
+//
+// Line은 0부터 시작하는 변경 시점의 라인 번호. 한 명령의 이벤트들은 실행 순서대로 전달되므로
+// 앞 이벤트를 반영한 번호임
+type ChangeEvent struct {
+	Kind   SyncStateCode
+	Line   int
+	Before []string
+	After  []string
+}
+
+// changeFeed: 구독자 목록과 아직 전달하지 않은 이벤트
+// op 실행 중의 이벤트는 트랜잭션이 끝날 때까지 모아뒀다가 성공했을 때만 전달 (롤백되면 버림)
+// 구독자가 없으면 이벤트 내용은 만들지 않고 바뀌었다는 것만 기록 (뷰의 dirty, 검색 갱신용)
+type changeFeed struct {
+	observers []changeObserver
+	nextID    int
+	pending   []ChangeEvent
+	changed   bool
+}
+
+type changeObserver struct {
+	id int
+	fn func(ChangeEvent)
+}
+
+// Subscribe: 문서 변경 구독. 돌려받은 함수를 부르면 구독 해제
+// fn은 편집 루프 안에서 동기적으로 불리므로 오래 걸리는 작업은 따로 넘길 것. fn 안에서 문서를 고치면 안 됨
+func (d *Document) Subscribe(fn func(ChangeEvent)) (unsubscribe func()) {
+	feed := &d.changes
+	id := feed.nextID
+	feed.nextID++
+	feed.observers = append(feed.observers, changeObserver{id: id, fn: fn})
+	return func() {
+		for i, o := range feed.observers {
+			if o.id == id {
+				feed.observers = append(feed.observers[:i:i], feed.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Subscribe: 뷰가 그리는 문서의 변경 구독 (Document.Subscribe와 같음)
+func (sp *SyncProtocol) Subscribe(fn func(ChangeEvent)) (unsubscribe func()) {
+	return sp.doc.Subscribe(fn)
+}
+
+// Observed: 구독자가 있는지. 없으면 EmitChange에 넘길 라인 텍스트를 만들 필요가 없음
+func (sp *SyncProtocol) Observed() bool {
+	return sp.doc.observed()
+}
+
+// EmitChange: 이벤트를 모아둠 (실행이 성공하면 전달, 구독자가 없으면 바뀌었다는 것만 기록)
+// 문서를 바꾸는 외부 op는 Execute 안에서 이걸로 변경을 알려야 구독자가 따라감
+func (sp *SyncProtocol) EmitChange(ev ChangeEvent) {
+	sp.doc.emitChange(ev)
+}
+
+func (d *Document) observed() bool {
+	return len(d.changes.observers) > 0
+}
+
+// emitChange: 호출하는 쪽은 observed()일 때만 ev의 내용을 채움 (아니면 빈 이벤트)
+func (d *Document) emitChange(ev ChangeEvent) {
+	d.changes.changed = true
+	if d.observed() {
+		d.changes.pending = append(d.changes.pending, ev)
+	}
+}
+
+// publishChanges: 뷰에 바뀐 것을 표시하고 모아둔 이벤트를 순서대로 모든 구독자에게 전달
+func (d *Document) publishChanges() {
+	changed, pending := d.changes.changed, d.changes.pending
+	d.changes.changed, d.changes.pending = false, nil
+	if changed && d.view != nil {
+		d.view.markChanged()
+	}
+	for _, ev := range pending {
+		for _, o := range d.changes.observers {
+			o.fn(ev)
+		}
+	}
+}
+
+// discardChanges: 롤백된 실행의 이벤트를 버림
+func (d *Document) discardChanges() {
+	d.changes.changed, d.changes.pending = false, nil
+}
+
+// markChanged: 문서가 바뀐 뒤 에디터 상태 갱신 (저장 필요, 검색 매치 다시 찾기)
+func (sp *SyncProtocol) markChanged() {
+	sp.dirty = true
+	if sp.search != nil {
+		sp.search.stale = true
+	}
+}
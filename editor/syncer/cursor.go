@@ -1,11 +1,10 @@
 package syncer
 
-import glp "go_editor/editor/screener/glyph"
-
 // Cursor: line-based로 커서를 저장
 type Cursor struct {
 	width, height  int
 	color          uint32
+	shape          CursorShape
 	capturedBuffer []uint32
 
 	currentLineBuffer *LineBuffer
@@ -29,6 +28,27 @@ func (sp *SyncProtocol) ClearCursor() {
 	sp.cursor.ClearCursor(sp)
 }
 
+// CursorShape: 커서 모양
+type CursorShape int
+
+const (
+	CursorBar       CursorShape = iota // 글자 앞의 세로 막대
+	CursorBlock                        // 글자 칸 전체
+	CursorUnderline                    // 글자 아래 가로 막대
+)
+
+// SetCursorShape: 모양에 맞게 커서 크기 변경 후 다시 그림
+func (sp *SyncProtocol) SetCursorShape(shape CursorShape) {
+	c := sp.cursor
+	wasVisible := c.visible
+	c.ClearCursor(sp)
+	c.shape = shape
+	sp.resizeCursor()
+	if wasVisible {
+		c.CusorDrawOn(sp)
+	}
+}
+
 func NewCursor(width, height int, color uint32) *Cursor {
 	return &Cursor{
 		width:  width,
@@ -77,8 +97,18 @@ func (c *Cursor) CoordinateCursor(sp *SyncProtocol, lineBuffer *LineBuffer, char
 // mapInset2pixXY는 커서의 currentCharInset을 바탕으로, 커서의 좌상단 픽셀의 좌표를 리턴한다.
 // 즉, 커서의 인셋을 바탕으로 픽셀상의 스타팅 포인트 제공
 func (c *Cursor) mapInset2pixColRow(sp *SyncProtocol) (col int, row int) {
-	col = c.currentCharInset * glp.GlyphWidth
-	row = (sp.LineHeight - sp.cursor.height) / 2
+	// 탭 때문에 인셋과 화면상의 칸 수가 다를 수 있음
+	visualCol := c.currentCharInset
+	if node := sp.doc.data.findSyncNodeByLineBuffer(c.currentLineBuffer); node != nil && c.currentLineBuffer != nil {
+		visualCol = sp.visualColumn(node.PieceTable.String(), c.currentCharInset)
+	}
+	col = sp.gutterWidth + visualCol*sp.glyphWidth()
+	if c.shape == CursorUnderline {
+		// 글리프 바로 아래쪽에 붙임
+		row = (sp.LineHeight-sp.glyphHeight())/2 + sp.glyphHeight() - c.height
+	} else {
+		row = (sp.LineHeight - sp.cursor.height) / 2
+	}
 	return col, row
 
 }
@@ -0,0 +1,65 @@
+package syncer
+
+import "fmt"
+
+// LCS 테이블 크기 상한. 넘으면 전체를 바뀐 것으로 취급
+const maxDiffCells = 4_000_000
+
+// lineDiff: a(버퍼) -> b(디스크) 라인 단위 차이
+// 바뀐 라인만 "- ", "+ " 접두어로 돌려주고, 앞에 "@@ 라인번호" 헤더를 붙임
+func lineDiff(a, b []string) []string {
+	n, m := len(a), len(b)
+	if n*m > maxDiffCells {
+		out := []string{fmt.Sprintf("@@ 1 (%d -> %d 라인, 너무 커서 전체 비교)", n, m)}
+		for _, l := range a {
+			out = append(out, "- "+l)
+		}
+		for _, l := range b {
+			out = append(out, "+ "+l)
+		}
+		return out
+	}
+
+	// lcs[i][j] = a[i:], b[j:]의 최장 공통 부분 수열 길이
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	inHunk := false
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			inHunk = false
+			i++
+			j++
+			continue
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			if !inHunk {
+				out = append(out, fmt.Sprintf("@@ %d", i+1))
+				inHunk = true
+			}
+			out = append(out, "+ "+b[j])
+			j++
+		default:
+			if !inHunk {
+				out = append(out, fmt.Sprintf("@@ %d", i+1))
+				inHunk = true
+			}
+			out = append(out, "- "+a[i])
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,269 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document: 화면 없이 쓰는 문서
+// 라인버퍼를 만들지 않으므로 도구/테스트에서 라이브러리로 쓸 수 있고,
+// 에디터 화면(SyncProtocol)은 NewView로 Document 위에 붙이는 뷰
+// 텍스트는 TextStore에 두고 (기본은 라인별 SyncData), 위치 검사와 변경 이벤트는 여기서 처리
+//
+// 위치는 0부터 시작하는 라인과 글자(rune) 단위 칸. 오프셋은 라인 사이의 줄바꿈을 한 글자로 셈
+type Document struct {
+	store TextStore
+	// store가 *SyncData면 같은 값 (뷰는 노드를 직접 다룸), 아니면 nil
+	data    *SyncData
+	changes changeFeed
+	// 붙어 있는 뷰 (nil이면 화면 없음)
+	view *SyncProtocol
+}
+
+// Position: 문서 안의 위치
+type Position struct {
+	Line, Col int
+}
+
+// Range: [Start, End) 범위
+type Range struct {
+	Start, End Position
+}
+
+// ErrOutOfRange: 문서 밖의 위치/범위
+var ErrOutOfRange = errors.New("syncer: 문서 범위 밖입니다")
+
+// ErrNotLineStore: 라인별 저장(SyncData)이 아닌 문서에 뷰를 붙이려 함
+var ErrNotLineStore = errors.New("syncer: 라인별 저장 문서에만 뷰를 붙일 수 있습니다")
+
+// ErrUnsaved: 저장하지 않은 수정 내용이 있어서 거부됨
+var ErrUnsaved = errors.New("syncer: 저장하지 않은 수정 내용이 있습니다")
+
+// NewDocument: text로 라인별 저장 문서 생성 (\r\n, \n 모두 줄바꿈)
+func NewDocument(text string) *Document {
+	return newDocument(splitLines(text))
+}
+
+// NewDocumentOn: 주어진 저장 방식의 문서 (예: NewDocumentOn(NewPieceStore(text)))
+func NewDocumentOn(store TextStore) *Document {
+	d := &Document{}
+	d.setStore(store)
+	return d
+}
+
+// newDocument: 라인 목록으로 문서 생성. 라인이 없으면 빈 라인 하나
+func newDocument(lines []string) *Document {
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	return NewDocumentOn(buildSyncData(lines))
+}
+
+// newDocumentBytes: UTF-8 라인들로 문서 생성. 각 라인을 복사하지 않고 원본 버퍼로 씀
+func newDocumentBytes(lines [][]byte) *Document {
+	if len(lines) == 0 {
+		return newDocument(nil)
+	}
+	return NewDocumentOn(syncDataBytes(lines))
+}
+
+// syncDataBytes: UTF-8 라인들로 노드 리스트 생성 (lines는 비어 있지 않아야 함)
+func syncDataBytes(lines [][]byte) *SyncData {
+	data := &SyncData{}
+	var cur *SyncNode
+	for _, line := range lines {
+		cur = data.appendPieceTable(cur, NewPieceTableBytes(line))
+	}
+	return data
+}
+
+func (d *Document) setStore(store TextStore) {
+	d.store = store
+	d.data, _ = store.(*SyncData)
+}
+
+// LineCount: 라인 수
+func (d *Document) LineCount() int {
+	return d.store.LineCount()
+}
+
+// Line: i번째 라인의 텍스트
+func (d *Document) Line(i int) (string, error) {
+	if err := d.checkLine(i); err != nil {
+		return "", err
+	}
+	return d.store.Line(i), nil
+}
+
+// Lines: 모든 라인의 텍스트
+func (d *Document) Lines() []string {
+	return d.store.Lines()
+}
+
+// Text: 라인들을 \n으로 이은 전체 텍스트
+func (d *Document) Text() string {
+	return strings.Join(d.Lines(), "\n")
+}
+
+// Offset: 위치를 문서 처음부터의 글자 오프셋으로
+func (d *Document) Offset(pos Position) (int, error) {
+	if err := d.checkPos(pos); err != nil {
+		return 0, err
+	}
+	return d.store.Offset(pos), nil
+}
+
+// PositionAt: 글자 오프셋을 위치로. 줄바꿈 자리는 그 라인의 끝
+func (d *Document) PositionAt(offset int) (Position, error) {
+	if offset < 0 {
+		return Position{}, fmt.Errorf("%w: 오프셋 %d", ErrOutOfRange, offset)
+	}
+	pos, ok := d.store.PositionAt(offset)
+	if !ok {
+		return Position{}, fmt.Errorf("%w: 오프셋이 문서 끝을 넘음", ErrOutOfRange)
+	}
+	return pos, nil
+}
+
+// Insert: (line, col)에 text 삽입. 줄바꿈이 있으면 라인을 나눔
+func (d *Document) Insert(line, col int, text string) error {
+	if err := d.checkPos(Position{Line: line, Col: col}); err != nil {
+		return err
+	}
+	parts := splitLines(text)
+	var before string
+	if d.observed() {
+		before = d.store.Line(line)
+	}
+	tx := d.undoTx(line, line)
+	d.store.Insert(line, col, strings.Join(parts, "\n"))
+	last := line + len(parts) - 1
+	if !d.observed() {
+		d.emitChange(ChangeEvent{})
+	} else if len(parts) == 1 {
+		d.emitChange(ChangeEvent{Kind: NodeModified, Line: line, Before: []string{before}, After: []string{d.store.Line(line)}})
+	} else {
+		// 앞 라인이 나뉘고, 가운데 조각들이 새 라인으로 들어간 것으로 알림
+		d.emitChange(ChangeEvent{
+			Kind:   NodeSliced,
+			Line:   line,
+			Before: []string{before},
+			After:  []string{d.store.Line(line), d.store.Line(last)},
+		})
+		for i, part := range parts[1 : len(parts)-1] {
+			d.emitChange(ChangeEvent{Kind: NodeInserted, Line: line + 1 + i, After: []string{part}})
+		}
+	}
+	end := Position{Line: last, Col: utf8.RuneCountInString(parts[len(parts)-1])}
+	if len(parts) == 1 {
+		end.Col += col
+	}
+	d.edited(line, last, end, tx)
+	return nil
+}
+
+// Delete: 범위의 텍스트 삭제. 여러 라인에 걸치면 첫 라인과 마지막 라인의 남은 부분을 이음
+func (d *Document) Delete(r Range) error {
+	start, end := r.Start, r.End
+	if end.Line < start.Line || (end.Line == start.Line && end.Col < start.Col) {
+		start, end = end, start
+	}
+	if err := d.checkPos(start); err != nil {
+		return err
+	}
+	if err := d.checkPos(end); err != nil {
+		return err
+	}
+	tx := d.undoTx(start.Line, end.Line)
+	if !d.observed() {
+		d.store.Delete(start, end)
+		d.emitChange(ChangeEvent{})
+		d.edited(start.Line, start.Line, start, tx)
+		return nil
+	}
+	before := d.store.Line(start.Line)
+	var removed []string
+	for i := start.Line + 1; i <= end.Line; i++ {
+		removed = append(removed, d.store.Line(i))
+	}
+	d.store.Delete(start, end)
+	// 뒤 라인들이 하나씩 빠지고 첫 라인이 바뀐 것으로 알림
+	for _, text := range removed {
+		d.emitChange(ChangeEvent{Kind: NodeDeleted, Line: start.Line + 1, Before: []string{text}})
+	}
+	d.emitChange(ChangeEvent{Kind: NodeModified, Line: start.Line, Before: []string{before}, After: []string{d.store.Line(start.Line)}})
+	d.edited(start.Line, start.Line, start, tx)
+	return nil
+}
+
+// undoTx: 뷰가 있으면 바뀔 [from, to] 라인 노드를 되돌리기용으로 저장 (화면 없는 문서는 nil)
+func (d *Document) undoTx(from, to int) *opTx {
+	if d.view == nil {
+		return nil
+	}
+	tx := newTx(d.view)
+	node, _ := d.data.findNode(uint(from))
+	for i := from; i <= to && node != nil; i++ {
+		tx.capture(node)
+		node = node.next
+	}
+	return tx
+}
+
+// edited: API로 바꾼 뒤 구독자에게 알리고 뷰를 갱신. [from, to] 라인이 바뀌었고 at은 편집이 끝난 위치
+func (d *Document) edited(from, to int, at Position, tx *opTx) {
+	d.publishChanges()
+	if d.view != nil {
+		d.view.documentEdited(from, to, at)
+		d.view.pushUndo(tx)
+		d.view.breakUndoGroup()
+	}
+}
+
+// checkLine: 라인 번호가 문서 안인지
+func (d *Document) checkLine(i int) error {
+	if i < 0 || i >= d.store.LineCount() {
+		return fmt.Errorf("%w: 라인 %d", ErrOutOfRange, i)
+	}
+	return nil
+}
+
+// checkPos: 위치가 문서 안인지 (라인 끝 위치도 허용)
+func (d *Document) checkPos(pos Position) error {
+	if err := d.checkLine(pos.Line); err != nil {
+		return err
+	}
+	if pos.Col < 0 || pos.Col > d.store.LineLength(pos.Line) {
+		return fmt.Errorf("%w: 라인 %d의 %d번째 칸", ErrOutOfRange, pos.Line, pos.Col)
+	}
+	return nil
+}
+
+// documentEdited: Document API로 바뀐 [from, to] 라인을 다시 그림 (op 실행은 SYNC op가 따로 그림)
+// 커서/선택 시작점의 라인이 지워졌으면 편집이 끝난 위치로 옮김
+func (sp *SyncProtocol) documentEdited(from, to int, at Position) {
+	sp.withCursorHidden(func() {
+		node, _ := sp.doc.data.findNode(uint(from))
+		for i := from; i <= to && node != nil; i++ {
+			sp.syncNode(node)
+			node = node.next
+		}
+		node = sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+		if node == nil {
+			node, _ = sp.doc.data.findNode(uint(at.Line))
+			sp.cursor.currentLineBuffer = node.LineBuffer
+			sp.cursor.currentCharInset = at.Col
+		}
+		sp.cursor.currentCharInset = min(sp.cursor.currentCharInset, node.PieceTable.Length())
+		if sp.mark != nil && sp.doc.data.findOrder(sp.mark.node) < 0 {
+			sp.mark = nil
+		}
+		sp.refreshSelection()
+	})
+	sp.refreshCurrentLine()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+}
@@ -1,120 +1,153 @@
 package syncer
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
-	"go_editor/editor/handlefile"
-	glp "go_editor/editor/screener/glyph"
-	"log"
-	"os"
-	"path/filepath"
+	"go_editor/editor/charset"
+	"go_editor/editor/storage"
+	"io/fs"
 	"strings"
 )
 
 // ----------------------------------------------------
 // (3) SyncProtocol 생성자 with file loading
 // ----------------------------------------------------
-func LoadSyncProtocol(screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
-	// 환경변수에서 SAVE_TXT 경로 가져오기
-	filePath := handlefile.GetSaveTxtPath()
-	log.Printf("Using SAVE_TXT path: %s", filePath)
+func LoadSyncProtocol(st storage.Storage, screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
+	name := storageName(st)
+	logger := defaultLogger.With("storage", name)
 
-	lineCount := screenHeight / LineHeight
-	syncData := &SyncData{}
-
-	// 파일이 존재하면 파일 내용 로드
-	var lines []string
-	fileData, err := os.ReadFile(filePath)
+	// 저장소에 내용이 있으면 로드 (매핑할 수 있으면 복사하지 않고 매핑)
+	var lines [][]byte
+	encoding := charset.UTF8
+	eol := EOLLF
+	fileData, unmap, err := loadStorage(st)
 	if err == nil {
-		// 파일 내용을 라인별로 분리
-		fileLines := strings.Split(string(fileData), "\n")
-		// Windows 파일에서 \r\n 처리를 위해 \r 제거
-		for _, line := range fileLines {
-			lines = append(lines, strings.TrimRight(line, "\r"))
+		// BOM/내용으로 인코딩 추측 후 UTF-8 바이트로 (UTF-8 파일은 복사 없음)
+		var text []byte
+		encoding = charset.Detect(fileData)
+		text, err = charset.DecodeBytes(fileData, encoding)
+		if err != nil {
+			logger.Warn("디코딩 실패, UTF-8로 읽음", "encoding", encoding, "err", err)
+			encoding = charset.UTF8
+			text = fileData
 		}
-		log.Printf("✅ %d 라인을 로드했습니다. 파일: %s", len(lines), filePath)
+		lines = splitLineBytes(text)
+		eol = detectEOL(text)
+		logger.Info("로드", "lines", len(lines), "encoding", encoding)
 	} else {
 		// 파일이 없는 경우 빈 문서로 처리
-		if os.IsNotExist(err) {
-			log.Printf("⚠️ 파일이 존재하지 않습니다. 빈 문서로 시작합니다.")
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Info("파일이 없어 빈 문서로 시작")
 		} else {
-			log.Printf("⚠️ 파일 로드 오류: %v", err)
+			logger.Warn("파일 로드 오류", "err", err)
 		}
 	}
 
-	// 파일 내용으로 노드 생성
-	var curNode *SyncNode = nil
-	for i := range lineCount {
-		if i < len(lines) {
-			// 파일에서 읽은 라인으로 노드 추가
-			curNode = syncData.appendByPtr(curNode, lines[i])
-		} else {
-
-			// 파일 라인이 부족하면 빈 라인 추가
-			curNode = syncData.appendByPtr(curNode, "")
+	// 파일 내용으로 문서를 만들고 뷰를 붙임
+	sp := newView(newDocumentBytes(lines), st, screenWidth, screenHeight, fg, bg, LineHeight)
+	sp.encoding = encoding
+	sp.eol = eol
+	sp.disk = fingerprint(fileData)
+	sp.unmap = unmap
+	logger.Debug("라인 초기화", "lines", sp.doc.LineCount())
+	return sp
+}
 
+// loadStorage: 저장소 내용. Mapper면 읽기 전용 매핑을 쓰고 해제 함수를 같이 돌려줌 (복사했으면 nil)
+// 매핑은 PieceTable의 원본 버퍼가 가리키므로 문서를 바꾸거나 뷰를 닫을 때 해제함 (releaseMapping)
+func loadStorage(st storage.Storage) ([]byte, func() error, error) {
+	if m, ok := st.(storage.Mapper); ok {
+		data, unmap, err := m.Map()
+		if err == nil {
+			return data, unmap, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			defaultLogger.Warn("매핑 실패, 복사해서 읽음", "err", err)
 		}
 	}
-	PrintList2(syncData.head)
-	sp := &SyncProtocol{
-		screenWidth:   screenWidth,
-		screenHeight:  screenHeight,
-		LineHeight:    LineHeight,
-		fgColor:       fg,
-		bgColor:       bg,
-		syncData:      syncData,
-		SyncStateCode: NodeModified,
-		changedNode:   nil,
-		cursor:        NewCursor(2, glp.GlyphHeight, 0xFF000000),
-	}
-
-	//여기서 워킹 통해서 각 노드마다 싱크 맞춰줌
-	syncData.ForEach(func(sn *SyncNode) {
-		sp.syncNode(sn)
-	})
-
-	//커서 위치 0,0으로 이동
-	sp.cursor.currentLineBuffer = sp.syncData.head.LineBuffer
-	sp.cursor.currentCharInset = 0
-	log.Printf("✅ %d 라인 초기화 완료", lineCount)
-	PrintList2(sp.syncData.head)
-	return sp
+	data, err := st.Load()
+	return data, nil, err
 }
 
-// PrintList: head부터 next 방향으로 순회하며 출력
-func PrintList2(head *SyncNode) {
-	if head == nil {
-		fmt.Println("Empty list.")
+// releaseMapping: 불러올 때 만든 매핑을 해제. 매핑을 가리키는 노드가 남아 있지 않을 때만 호출
+func (sp *SyncProtocol) releaseMapping() {
+	if sp.unmap == nil {
 		return
 	}
-	cur := head
-	i := 0
-	for cur != nil {
-		if cur.PieceTable.String() == "" {
-			println("빈 텍스트 발견- 종료")
-			return
-		}
-		f := false
-		if cur.LineBuffer == nil {
-			f = true
-		}
-		fmt.Printf(" Node[%d]: %q void: %v\n", i, cur.PieceTable.String(), f)
-		cur = cur.next
-		i++
+	if err := sp.unmap(); err != nil {
+		sp.logger.Warn("매핑 해제 실패", "err", err)
 	}
+	sp.unmap = nil
 }
 
-// SaveToFile 환경변수에 정의된 위치에 문서 저장
-func (sp *SyncProtocol) SaveToFile() error {
-	filePath := handlefile.GetSaveTxtPath()
+// diskFingerprint: 마지막으로 읽거나 쓴 파일 내용의 크기와 해시 (외부 변경 감지용)
+// 읽은 내용은 파일 매핑일 수 있어서 (파일이 제자리에서 바뀌면 같이 바뀌고 닫으면 해제됨) 바이트를 들고 있지 않음
+type diskFingerprint struct {
+	size int
+	sum  [sha256.Size]byte
+}
 
-	// 내용을 파일로 저장하기 위한 텍스트 수집
+func fingerprint(data []byte) diskFingerprint {
+	return diskFingerprint{size: len(data), sum: sha256.Sum256(data)}
+}
+
+// storageName: 로그용 저장소 이름
+func storageName(st storage.Storage) string {
+	info, _ := st.Stat()
+	return info.Name
+}
+
+// splitLines: 파일 내용을 라인별로 분리
+func splitLines(text string) []string {
 	var lines []string
+	fileLines := strings.Split(text, "\n")
+	// Windows 파일에서 \r\n 처리를 위해 \r 제거
+	for _, line := range fileLines {
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines
+}
 
-	sp.syncData.ForEach(func(sn *SyncNode) {
-		// PieceTable에서 문자열 가져오기
-		lineText := sn.PieceTable.String()
-		lines = append(lines, lineText)
-	})
+// splitLineBytes: splitLines와 같지만 복사하지 않고 text의 일부를 가리킴
+func splitLineBytes(text []byte) [][]byte {
+	lines := bytes.Split(text, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, "\r")
+	}
+	return lines
+}
+
+// 줄바꿈 방식
+const (
+	EOLLF   = "\n"
+	EOLCRLF = "\r\n"
+)
+
+// detectEOL: 첫 줄바꿈이 \r\n이면 CRLF 파일로 봄 (저장할 때 그대로 유지)
+func detectEOL(text []byte) string {
+	if i := bytes.IndexByte(text, '\n'); i > 0 && text[i-1] == '\r' {
+		return EOLCRLF
+	}
+	return EOLLF
+}
+
+// buildSyncData: lines로 노드 리스트 생성 (lines는 비어 있지 않아야 함)
+// 화면보다 긴 파일도 전부 노드로 만들고, 보이는 범위는 뷰포트가 정함
+func buildSyncData(lines []string) *SyncData {
+	syncData := &SyncData{}
+	var curNode *SyncNode = nil
+	for _, line := range lines {
+		curNode = syncData.appendByPtr(curNode, line)
+	}
+	return syncData
+}
+
+// documentLines: 저장할 라인들 수집 (마지막의 빈 라인들은 제외)
+func (sp *SyncProtocol) documentLines() []string {
+	// 내용을 파일로 저장하기 위한 텍스트 수집
+	lines := sp.doc.Lines()
 
 	// 마지막의 빈 라인들은 저장하지 않음 (실제 문서 내용만 저장)
 	for i := len(lines) - 1; i >= 0; i-- {
@@ -127,22 +160,190 @@ func (sp *SyncProtocol) SaveToFile() error {
 			lines = []string{""}
 		}
 	}
+	return lines
+}
+
+// SaveToFile 주입된 저장소에 문서 저장 (불러올 때의 인코딩 유지)
+func (sp *SyncProtocol) SaveToFile() error {
+	lines := sp.documentLines()
+
+	// 내용을 저장소에 저장
+	content, err := charset.Encode(strings.Join(lines, sp.eol), sp.encoding)
+	if err != nil {
+		return err
+	}
+	err = sp.storage.Save(content)
+	if err != nil {
+		return err
+	}
+	// 방금 쓴 내용을 기억해둬야 자기 자신의 저장을 외부 변경으로 착각하지 않음
+	sp.disk = fingerprint(content)
+	sp.dirty = false
+
+	sp.logger.Info("저장", "lines", len(lines), "storage", storageName(sp.storage))
+	sp.SetMessage("Saved %d lines", len(lines))
+	return nil
+}
 
-	// 저장 디렉토리가 존재하는지 확인하고 없으면 생성
-	dir := filepath.Dir(filePath)
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("디렉토리 생성 실패: %v", err)
+// SaveIfDirty: 수정된 내용이 있을 때만 저장
+// 종료시 이걸 써야 외부 도구가 바꾼 파일을 깨끗한 버퍼로 덮어쓰지 않음
+func (sp *SyncProtocol) SaveIfDirty() error {
+	if !sp.dirty {
+		return nil
+	}
+	return sp.SaveToFile()
+}
+
+// WatchStorage: 저장소의 외부 변경 감시 시작
+func (sp *SyncProtocol) WatchStorage() (storage.Watcher, error) {
+	return sp.storage.Watch()
+}
+
+// IsDirty: 마지막 로드/저장 이후 문서가 수정되었는지
+func (sp *SyncProtocol) IsDirty() bool {
+	return sp.dirty
+}
+
+// FileChangedOnDisk: 디스크의 파일이 마지막으로 읽거나 쓴 내용과 다른지 확인
+// 대용량 파일 모드는 연 시점의 내용을 계속 보여주므로 (다시 읽으려면 다시 열기) 확인하지 않음
+func (sp *SyncProtocol) FileChangedOnDisk() (bool, error) {
+	if sp.large != nil {
+		return false, nil
+	}
+	fileData, err := sp.storage.Load()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// 지워진 경우는 변경으로 보지 않음 (종료시 다시 저장됨)
+			return false, nil
 		}
+		return false, err
+	}
+	return fingerprint(fileData) != sp.disk, nil
+}
+
+// AcknowledgeFileChange: 외부 변경을 확인만 하고 현재 버퍼를 유지
+// 디스크 내용을 기준으로 삼아서 같은 변경에 대해 다시 묻지 않게 함
+// 버퍼는 dirty로 남아서 종료시 사용자 버전으로 저장됨
+func (sp *SyncProtocol) AcknowledgeFileChange() error {
+	fileData, err := sp.storage.Load()
+	if err != nil {
+		return err
 	}
+	sp.disk = fingerprint(fileData)
+	sp.dirty = true
+	return nil
+}
 
-	// 내용을 파일로 저장
-	content := strings.Join(lines, "\n")
-	err := os.WriteFile(filePath, []byte(content), 0644)
+// ReloadFromFile: 파일을 다시 읽어서 문서 전체를 교체 (인코딩도 다시 추측)
+func (sp *SyncProtocol) ReloadFromFile() error {
+	fileData, err := sp.storage.Load()
 	if err != nil {
 		return err
 	}
+	return sp.reloadWithEncoding(fileData, charset.Detect(fileData))
+}
 
-	log.Printf("✅ %d 라인을 파일에 저장했습니다: %s", len(lines), filePath)
+// ReopenWithEncoding: 파일을 지정한 인코딩으로 다시 읽음
+// 잘못 추측된 인코딩을 바로잡는 용도라서 수정중인 내용이 있으면 거부
+func (sp *SyncProtocol) ReopenWithEncoding(enc charset.Encoding) error {
+	if sp.dirty {
+		return fmt.Errorf("%w: %s로 다시 열 수 없습니다 (먼저 저장하세요)", ErrUnsaved, enc)
+	}
+	fileData, err := sp.storage.Load()
+	if err != nil {
+		return err
+	}
+	return sp.reloadWithEncoding(fileData, enc)
+}
+
+// SaveWithEncoding: 인코딩을 바꿔서 저장. 이후 저장도 이 인코딩을 따름
+func (sp *SyncProtocol) SaveWithEncoding(enc charset.Encoding) error {
+	prev := sp.encoding
+	sp.encoding = enc
+	if err := sp.SaveToFile(); err != nil {
+		sp.encoding = prev
+		return err
+	}
 	return nil
 }
+
+// Encoding: 현재 문서의 저장 인코딩
+func (sp *SyncProtocol) Encoding() charset.Encoding {
+	return sp.encoding
+}
+
+// reloadWithEncoding: fileData를 enc로 해석해서 문서 전체를 교체
+// 커서는 가능한 한 같은 라인/위치에 남겨둔다.
+func (sp *SyncProtocol) reloadWithEncoding(fileData []byte, enc charset.Encoding) error {
+	text, err := charset.DecodeBytes(fileData, enc)
+	if err != nil {
+		return err
+	}
+
+	// 기존 커서 위치 유지 (라인 수가 줄었으면 마지막 라인으로)
+	sp.rebuildDocument(splitLines(string(text)), sp.cursorLineIndex(), sp.cursor.currentCharInset)
+
+	// 되돌리기 기록은 옛 노드 리스트를 가리키므로 버림
+	sp.clearUndo()
+	sp.encoding = enc
+	sp.eol = detectEOL(text)
+	sp.disk = fingerprint(fileData)
+	sp.dirty = false
+	// 옛 노드와 되돌리기 기록을 버렸으므로 처음 불러올 때의 매핑은 더 쓰지 않음
+	sp.releaseMapping()
+	sp.logger.Info("다시 불러옴", "storage", storageName(sp.storage), "encoding", enc)
+	return nil
+}
+
+// rebuildDocument: lines로 노드 리스트를 새로 만들고 모든 라인을 다시 그림
+// 커서는 (cursorLine, cursorInset)에 가장 가까운 곳으로
+func (sp *SyncProtocol) rebuildDocument(lines []string, cursorLine, cursorInset int) {
+	// 이전 라인버퍼에 그려진 커서는 버림 (라인버퍼 자체가 새로 만들어짐)
+	sp.cursor.visible = false
+	sp.cursor.capturedBuffer = nil
+	sp.highlightedNode = nil
+	sp.mark = nil
+
+	ev := ChangeEvent{Kind: DocumentReplaced}
+	if sp.doc.observed() {
+		ev.Before = sp.doc.Lines()
+	}
+	sp.doc.setStore(buildSyncData(lines))
+	sp.cursor.currentLineBuffer = nil
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		sp.syncNode(sn)
+	})
+	if sp.doc.observed() {
+		ev.After = sp.doc.Lines()
+	}
+	sp.EmitChange(ev)
+	sp.doc.publishChanges()
+
+	node, found := sp.doc.data.findNode(uint(max(cursorLine, 0)))
+	if !found {
+		node = sp.doc.data.head
+		for !node.IsDownEnd() {
+			node = node.next
+		}
+	}
+	sp.cursor.currentLineBuffer = node.LineBuffer
+	sp.cursor.currentCharInset = min(max(cursorInset, 0), node.PieceTable.Length())
+	sp.refreshCurrentLine()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+}
+
+// DiffWithFile: 현재 버퍼와 디스크의 파일을 라인 단위로 비교한 결과
+func (sp *SyncProtocol) DiffWithFile() (string, error) {
+	fileData, err := sp.storage.Load()
+	if err != nil {
+		return "", err
+	}
+	text, err := charset.Decode(fileData, charset.Detect(fileData))
+	if err != nil {
+		return "", err
+	}
+	diskLines := splitLines(text)
+	return strings.Join(lineDiff(sp.documentLines(), diskLines), "\n"), nil
+}
@@ -0,0 +1,134 @@
+package syncer
+
+import (
+	"strconv"
+)
+
+// GutterMode: 라인 왼쪽 줄 번호 표시 방식
+type GutterMode int
+
+const (
+	GutterOff      GutterMode = iota
+	GutterAbsolute            // 1부터 시작하는 줄 번호
+	GutterRelative            // 커서 라인과의 거리 (커서 라인은 절대 번호)
+)
+
+// 줄 번호 최소 자리수와 텍스트와의 간격(칸)
+const (
+	gutterMinDigits = 2
+	gutterPadding   = 1
+)
+
+// SetGutterMode: 줄 번호 표시 방식 변경
+func (sp *SyncProtocol) SetGutterMode(mode GutterMode) {
+	sp.gutterMode = mode
+	sp.updateGutter()
+}
+
+// GutterMode: 현재 줄 번호 표시 방식
+func (sp *SyncProtocol) GutterMode() GutterMode {
+	return sp.gutterMode
+}
+
+// gutterWidthFor: 줄 번호 칸의 픽셀 폭. 자리수에 맞춰 늘어남
+func (sp *SyncProtocol) gutterWidthFor(lineCount int) int {
+	if sp.gutterMode == GutterOff {
+		return 0
+	}
+	digits := max(len(strconv.Itoa(lineCount)), gutterMinDigits)
+	return (digits + gutterPadding) * sp.glyphWidth()
+}
+
+// updateGutter: 라인 수/배율/모드가 바뀌어 거터 폭이 달라졌으면 모든 라인의 텍스트 위치를 다시 그림
+func (sp *SyncProtocol) updateGutter() {
+	width := sp.gutterWidthFor(sp.totalLines())
+	if width == sp.gutterWidth {
+		return
+	}
+	sp.gutterWidth = width
+	sp.rerenderAll()
+}
+
+// drawGutter: 화면에 보낼 라인 복사본에 줄 번호를 그림
+// 번호는 노드 위치에서 계산하므로 라인버퍼에는 거터 자리만 비워둠
+func (sp *SyncProtocol) drawGutter(l *LineBuffer, index, cursorIndex int) {
+	if sp.gutterWidth == 0 {
+		return
+	}
+	fg, bg := sp.fgColor, sp.bgColor
+	if sp.theme != nil {
+		fg, bg = uint32(sp.theme.Gutter.Foreground), uint32(sp.theme.Gutter.Background)
+	}
+	for row := 0; row < sp.LineHeight; row++ {
+		start := row * sp.screenWidth
+		for x := 0; x < sp.gutterWidth && x < sp.screenWidth; x++ {
+			l.data[start+x] = bg
+		}
+	}
+
+	number := index + 1
+	if sp.gutterMode == GutterRelative && index != cursorIndex {
+		number = abs(index - cursorIndex)
+	}
+	label := strconv.Itoa(number)
+	// 오른쪽 정렬, 텍스트와는 gutterPadding칸 띄움
+	x := sp.gutterWidth - (len(label)+gutterPadding)*sp.glyphWidth()
+	yOffset := (sp.LineHeight - sp.glyphHeight()) / 2
+	for _, ch := range label {
+		sp.drawGlyphToLine(l, x, yOffset, glyphFor(ch), fg)
+		x += sp.glyphWidth()
+	}
+}
+
+// pixelToPosition: 창 좌표 -> (노드, 글자 인셋). 거터/빈 영역 클릭은 가장 가까운 위치로
+func (sp *SyncProtocol) pixelToPosition(x, y int) (*SyncNode, int) {
+	if y < 0 {
+		y = 0
+	}
+	index := min(sp.viewTop+y/sp.LineHeight, sp.LineCount()-1)
+	node, found := sp.doc.data.findNode(uint(max(index, 0)))
+	if !found {
+		return nil, 0
+	}
+	col := max(x-sp.gutterWidth, 0) / sp.glyphWidth()
+	return node, sp.insetForVisualColumn(node.PieceTable.String(), col)
+}
+
+// insetForVisualColumn: 화면 칸 -> 글자 인셋 (탭 칸 안쪽은 탭 앞 글자로)
+func (sp *SyncProtocol) insetForVisualColumn(text string, target int) int {
+	col := 0
+	inset := 0
+	for _, ch := range text {
+		next := col + 1
+		if ch == '\t' {
+			next = sp.nextTabStop(col)
+		}
+		if target < next {
+			// 칸의 오른쪽 절반을 누르면 다음 글자 앞
+			if target-col >= (next-col+1)/2 && next-col > 1 {
+				return inset + 1
+			}
+			return inset
+		}
+		col = next
+		inset++
+	}
+	return inset
+}
+
+// MoveCursorToPixel: 마우스 클릭 위치로 커서 이동
+func (sp *SyncProtocol) MoveCursorToPixel(x, y int) {
+	node, inset := sp.pixelToPosition(x, y)
+	if node == nil {
+		return
+	}
+	sp.cursor.currentLineBuffer = node.LineBuffer
+	sp.cursor.currentCharInset = inset
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
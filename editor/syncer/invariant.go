@@ -0,0 +1,114 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// debugChecks: 켜져 있으면 ExecuteAll 후마다 문서 전체 불변식 검사 (main의 -debug-ops)
+// 꺼져 있어도 건드린 노드와 커서는 항상 검사함 (tx.check)
+var debugChecks bool
+
+// SetDebugChecks: op 실행 불변식 검사 켜기/끄기
+func SetDebugChecks(on bool) {
+	debugChecks = on
+}
+
+// InvariantError: 불변식 위반 내용 + 그때까지 실행한 op 기록
+type InvariantError struct {
+	Violations []string
+	Trace      []string
+	RolledBack bool // 문서와 커서를 실행 전으로 되돌렸는지
+}
+
+func (e *InvariantError) Error() string {
+	state := ""
+	if e.RolledBack {
+		state = " (롤백됨)"
+	}
+	return fmt.Sprintf("op 불변식 위반%s: %s\n  op 기록:\n    %s",
+		state, strings.Join(e.Violations, "; "), strings.Join(e.Trace, "\n    "))
+}
+
+// executeChecked: op를 실행하고, 패닉은 잡아서 위반으로 보고
+// 실패한 op의 위치(없으면 마지막 op)와 위반 목록 리턴
+func (ops *OpSequences) executeChecked(sp *SyncProtocol, tx *opTx) (failedAt int, violations []string) {
+	debug := sp.logger.Enabled(context.Background(), slog.LevelDebug)
+	for i, op := range ops.ops {
+		if debug {
+			sp.logger.Debug("op 실행", "op", sp.formatOp(op))
+		}
+		if msg := runOp(op, sp); msg != "" {
+			return i, []string{msg}
+		}
+	}
+	violations = tx.check(sp)
+	if debugChecks {
+		violations = append(violations, sp.checkInvariants()...)
+	}
+	return len(ops.ops) - 1, violations
+}
+
+// trace: 0..upTo번째 op의 기록. 롤백 뒤에 불러야 라인 번호가 실행 전 기준
+func (ops *OpSequences) trace(sp *SyncProtocol, upTo int) []string {
+	var trace []string
+	for i := 0; i <= upTo && i < len(ops.ops); i++ {
+		trace = append(trace, sp.formatOp(ops.ops[i]))
+	}
+	return trace
+}
+
+func runOp(op Op, sp *SyncProtocol) (panicMsg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicMsg = fmt.Sprintf("%s 실행 중 패닉: %v", op.Info(), r)
+		}
+	}()
+	op.Execute(sp)
+	return ""
+}
+
+// checkInvariants: 리스트 링크, 노드 버퍼, 커서 위치 검사. 위반 목록 리턴
+func (sp *SyncProtocol) checkInvariants() []string {
+	var violations []string
+	head := sp.doc.data.head
+	if head == nil {
+		return []string{"리스트가 비어 있음"}
+	}
+	if head.prev != nil {
+		violations = append(violations, "head.prev가 nil이 아님")
+	}
+
+	cursorFound := false
+	visited := map[*SyncNode]bool{}
+	i := 0
+	for n := head; n != nil; n = n.next {
+		if visited[n] {
+			violations = append(violations, fmt.Sprintf("라인 %d: 리스트에 순환이 있음", i))
+			break
+		}
+		visited[n] = true
+		if n.next != nil && n.next.prev != n {
+			violations = append(violations, fmt.Sprintf("라인 %d: next.prev가 자신이 아님", i))
+		}
+		if n.LineBuffer == nil {
+			violations = append(violations, fmt.Sprintf("라인 %d: LineBuffer 없음", i))
+		}
+		if n.PieceTable == nil {
+			violations = append(violations, fmt.Sprintf("라인 %d: PieceTable 없음", i))
+		}
+		if n.LineBuffer != nil && n.LineBuffer == sp.cursor.currentLineBuffer {
+			cursorFound = true
+			if inset := sp.cursor.currentCharInset; n.PieceTable != nil && (inset < 0 || inset > n.PieceTable.Length()) {
+				violations = append(violations, fmt.Sprintf("라인 %d: 커서 인셋 %d가 라인 길이 %d 밖", i, inset, n.PieceTable.Length()))
+			}
+		}
+		i++
+	}
+	if !cursorFound {
+		violations = append(violations, "커서의 라인이 리스트에 없음")
+	}
+	return violations
+}
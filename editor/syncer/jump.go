@@ -0,0 +1,70 @@
+package syncer
+
+import (
+	"go_editor/editor/commander"
+)
+
+// processJumpCommand는 op 시퀀스 없이 커서를 옮기거나 라인을 통째로 바꾸는 명령을 처리함
+// (클릭, goto, 명령줄의 s///, 되돌리기, 선택 시작)
+func (sp *SyncProtocol) processJumpCommand(cmd commander.Command) bool {
+	switch cmd.Code {
+	case commander.CmdClick:
+		if input, ok := cmd.Input.(commander.ClickInput); ok {
+			sp.MoveCursorToPixel(input.Width, input.Height)
+		}
+	case commander.CmdGoto:
+		if input, ok := cmd.Input.(commander.LineInput); ok {
+			sp.GotoLine(input.Line)
+		}
+	case commander.CmdSubstitute:
+		input, ok := cmd.Input.(commander.SubstituteInput)
+		if !ok {
+			return true
+		}
+		count, err := sp.Substitute(input)
+		if err != nil {
+			sp.logger.Warn("치환 실패", "err", err)
+			sp.SetMessage("Substitute failed: %s", StatusError(err))
+		} else {
+			sp.SetMessage("%d substitutions", count)
+		}
+	case commander.CmdUndo:
+		if !sp.Undo() {
+			sp.SetMessage("Already at oldest change")
+		}
+	case commander.CmdRedo:
+		if !sp.Redo() {
+			sp.SetMessage("Already at newest change")
+		}
+	case commander.CmdSetMark:
+		if sp.SetMark() {
+			sp.SetMessage("Mark set")
+		} else {
+			sp.SetMessage("Mark cleared")
+		}
+	default:
+		return false
+	}
+	sp.breakUndoGroup()
+	sp.refreshCurrentLine()
+	sp.refreshSelection()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+	return true
+}
+
+// GotoLine: 1부터 시작하는 라인 번호로 커서 이동 (범위 밖이면 처음/끝 라인)
+func (sp *SyncProtocol) GotoLine(line int) {
+	if sp.large != nil {
+		// 대용량 파일은 파일 라인 번호 (색인된 마지막 줄까지)
+		sp.showLine(line-1, 0)
+		return
+	}
+	index := min(max(line-1, 0), sp.LineCount()-1)
+	node, found := sp.doc.data.findNode(uint(index))
+	if !found {
+		return
+	}
+	sp.cursor.currentLineBuffer = node.LineBuffer
+	sp.cursor.currentCharInset = 0
+}
@@ -0,0 +1,420 @@
+package syncer
+
+import (
+	"bytes"
+	"go_editor/editor/charset"
+	"go_editor/editor/commander"
+	"go_editor/editor/storage"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// lineStep: lineStep 줄마다 줄 시작 위치를 하나 기억함 (textBuffer의 runeStep과 같은 방식)
+const lineStep = 64
+
+// indexChunk: 색인 고루틴이 한 번에 훑는 바이트 수. 한 조각씩 끝날 때마다 화면에서 읽을 수 있음
+const indexChunk = 1 << 20
+
+// lineIndex: 큰 파일의 줄 색인. 고루틴 하나가 앞에서부터 채우고, 화면은 채워진 만큼만 읽음
+// data는 읽기 전용(보통 파일 매핑)이라 잠금 없이 읽고, 색인 필드만 mu로 보호
+type lineIndex struct {
+	data []byte
+
+	mu       sync.Mutex
+	marks    []int // marks[i] = i*lineStep번째 줄의 시작 바이트
+	started  int   // 시작 위치를 찾은 줄 수 (마지막 줄은 끝을 아직 모를 수 있음)
+	complete int   // 끝을 아는 줄들이 끝나는 바이트 (마지막으로 찾은 줄바꿈 바로 뒤)
+	scanned  int   // 훑은 바이트 수
+	done     bool
+	stop     chan struct{}
+	// 색인 고루틴이 끝나면 닫힘 (고루틴을 띄우지 않았으면 nil)
+	finished chan struct{}
+}
+
+func newLineIndex(data []byte) *lineIndex {
+	return &lineIndex{data: data, marks: []int{0}, started: 1, stop: make(chan struct{})}
+}
+
+// startIndexing: 남은 부분을 색인할 고루틴을 띄움 (테스트는 바꿔치워서 scanChunk를 직접 부름)
+var startIndexing = (*lineIndex).start
+
+func (ix *lineIndex) start() {
+	ix.finished = make(chan struct{})
+	go func() {
+		defer close(ix.finished)
+		ix.build()
+	}()
+}
+
+// build: 색인 고루틴. 파일 끝까지 훑거나 close될 때까지
+func (ix *lineIndex) build() {
+	for ix.scanChunk() {
+		select {
+		case <-ix.stop:
+			return
+		default:
+		}
+	}
+}
+
+// scanChunk: 다음 indexChunk 바이트를 훑어서 색인에 반영. 더 훑을 게 없으면 false
+// 색인을 쓰는 건 build 고루틴(과 그 전의 생성자)뿐이라 started/scanned는 잠그지 않고 읽음
+func (ix *lineIndex) scanChunk() bool {
+	pos, end := ix.scanned, min(ix.scanned+indexChunk, len(ix.data))
+	started, complete := ix.started, ix.complete
+	var marks []int
+	for i := pos; i < end; {
+		j := bytes.IndexByte(ix.data[i:end], '\n')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+		if started%lineStep == 0 {
+			marks = append(marks, i)
+		}
+		started++
+		complete = i
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.marks = append(ix.marks, marks...)
+	ix.started, ix.complete, ix.scanned = started, complete, end
+	if end == len(ix.data) {
+		ix.done = true
+		ix.complete = end
+	}
+	return !ix.done
+}
+
+// close: 색인 고루틴을 멈추고 끝날 때까지 기다림 (그 뒤에는 data를 해제해도 됨)
+func (ix *lineIndex) close() {
+	select {
+	case <-ix.stop:
+	default:
+		close(ix.stop)
+	}
+	if ix.finished != nil {
+		<-ix.finished
+	}
+}
+
+// count: 읽을 수 있는 줄 수. 색인중이면 끝을 아는 줄까지
+func (ix *lineIndex) count() int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.countLocked()
+}
+
+func (ix *lineIndex) countLocked() int {
+	if ix.done {
+		return ix.started
+	}
+	return ix.started - 1
+}
+
+// progress: 읽을 수 있는 줄 수, 훑은 비율(%), 완료 여부
+func (ix *lineIndex) progress() (lines, percent int, done bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	percent = 100
+	if len(ix.data) > 0 {
+		percent = int(int64(ix.scanned) * 100 / int64(len(ix.data)))
+	}
+	return ix.countLocked(), percent, ix.done
+}
+
+// lineStart: i번째 줄의 시작 바이트 (i < count)
+func (ix *lineIndex) lineStart(i int) int {
+	ix.mu.Lock()
+	off := ix.marks[i/lineStep]
+	ix.mu.Unlock()
+	for range i % lineStep {
+		off += bytes.IndexByte(ix.data[off:], '\n') + 1
+	}
+	return off
+}
+
+// lineAt: 바이트 offset이 들어 있는 줄 번호 (offset < complete)
+func (ix *lineIndex) lineAt(offset int) int {
+	ix.mu.Lock()
+	k := sort.SearchInts(ix.marks, offset+1) - 1
+	start := ix.marks[k]
+	ix.mu.Unlock()
+	return k*lineStep + bytes.Count(ix.data[start:offset], []byte("\n"))
+}
+
+// lines: [from, from+n) 줄의 바이트 (줄바꿈 제외, 복사 없음). count를 넘는 줄은 빠짐
+func (ix *lineIndex) lines(from, n int) [][]byte {
+	n = min(n, ix.count()-from)
+	if n <= 0 {
+		return nil
+	}
+	out := make([][]byte, 0, n)
+	off := ix.lineStart(from)
+	for range n {
+		end := bytes.IndexByte(ix.data[off:], '\n')
+		if end < 0 {
+			end = len(ix.data) - off
+		}
+		out = append(out, bytes.TrimRight(ix.data[off:off+end], "\r"))
+		off += end + 1
+	}
+	return out
+}
+
+// searchLimit: 검색할 수 있는 바이트 끝 (끝을 아는 줄까지)
+func (ix *lineIndex) searchLimit() int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.complete
+}
+
+// largeFile: 대용량 파일 모드의 상태
+// 문서(doc)에는 파일의 top번째 줄부터 화면 주변 몇 화면 분량만 노드/라인버퍼로 만들어 두고,
+// 커서가 그 끝에 다가가면 커서 주변으로 다시 만듦. 뷰의 라인 번호(viewTop 등)는 이 창 안의 번호
+type largeFile struct {
+	index *lineIndex
+	top   int
+	// 파일 매핑의 해제 함수 (매핑하지 않았거나 이미 해제했으면 nil)
+	unmap func() error
+}
+
+// close: 색인을 멈춘 뒤 매핑을 해제. 여러 번 불러도 됨
+func (lf *largeFile) close() error {
+	lf.index.close()
+	if lf.unmap == nil {
+		return nil
+	}
+	err := lf.unmap()
+	lf.unmap = nil
+	return err
+}
+
+// detectSample: 인코딩을 추측할 때 보는 파일 앞부분 크기
+const detectSample = 64 << 10
+
+// detectLargeEncoding: 파일 앞부분으로 인코딩 추측. 잘린 끝의 UTF-8 글자는 빼고 봄
+func detectLargeEncoding(data []byte) charset.Encoding {
+	sample := data[:min(len(data), detectSample)]
+	if len(sample) < len(data) {
+		i := len(sample) - 1
+		for i > 0 && i > len(sample)-utf8.UTFMax && !utf8.RuneStart(sample[i]) {
+			i--
+		}
+		if !utf8.FullRune(sample[i:]) {
+			sample = sample[:i]
+		}
+	}
+	return charset.Detect(sample)
+}
+
+// windowScreens: 창에 화면 위/아래로 더 만들어 두는 화면 수
+const windowScreens = 2
+
+// OpenLargeFile: 대용량 파일을 읽기 전용으로 여는 뷰
+// 파일은 매핑할 수 있으면 매핑하고, 줄 색인은 백그라운드에서 만듦. 그동안 색인된 부분은 스크롤/검색 가능
+// Document()는 지금 만들어진 창만 담고 있으며, 편집/저장 명령은 거부함
+// 바이트를 그대로 보여주므로 UTF-8 파일만 이 모드로 열고, 앞부분으로 추측한 인코딩이 다르면
+// 전체를 변환해야 하므로 일반 모드로 열고 상태 표시줄에 알림
+func OpenLargeFile(st storage.Storage, screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
+	logger := defaultLogger.With("storage", storageName(st))
+	data, unmap, err := loadStorage(st)
+	if err != nil {
+		logger.Warn("대용량 파일 로드 오류, 빈 문서로 시작", "err", err)
+		return NewSyncProtocol(st, screenWidth, screenHeight, fg, bg, LineHeight)
+	}
+	encoding := detectLargeEncoding(data)
+	if encoding != charset.UTF8 && encoding != charset.UTF8BOM {
+		if unmap != nil {
+			if err := unmap(); err != nil {
+				logger.Warn("매핑 해제 실패", "err", err)
+			}
+		}
+		logger.Info("UTF-8이 아니라 일반 모드로 엶", "encoding", encoding)
+		sp := LoadSyncProtocol(st, screenWidth, screenHeight, fg, bg, LineHeight)
+		sp.SetMessage("Large file is %s, opened normally", encoding)
+		return sp
+	}
+	if rest, ok := bytes.CutPrefix(data, []byte{0xEF, 0xBB, 0xBF}); ok {
+		data = rest
+	}
+	index := newLineIndex(data)
+	// 첫 화면은 바로 보여야 하므로 첫 조각은 여기서 색인
+	if index.scanChunk() {
+		startIndexing(index)
+	}
+
+	visible := max(screenHeight/LineHeight, 1)
+	lines := index.lines(0, visible*(2*windowScreens+1))
+	sp := newView(NewDocumentOn(syncDataBytes(lines)), st, screenWidth, screenHeight, fg, bg, LineHeight)
+	sp.large = &largeFile{index: index, unmap: unmap}
+	sp.encoding = encoding
+	sp.eol = detectEOL(data)
+	logger.Info("대용량 파일 모드로 엶", "bytes", len(data))
+	return sp
+}
+
+// LargeFile: 대용량 파일 모드(읽기 전용)인지
+func (sp *SyncProtocol) LargeFile() bool {
+	return sp.large != nil
+}
+
+// Close: 뷰를 버릴 때 호출. 대용량 파일의 색인 고루틴을 멈추고 파일 매핑을 해제함
+// 닫은 뒤에는 뷰와 문서를 쓰면 안 됨 (노드가 매핑을 가리킬 수 있음). 여러 번 불러도 됨
+func (sp *SyncProtocol) Close() {
+	if sp.large != nil {
+		if err := sp.large.close(); err != nil {
+			sp.logger.Warn("매핑 해제 실패", "err", err)
+		}
+	}
+	sp.releaseMapping()
+}
+
+// lineBase: 노드 리스트 첫 줄의 파일 라인 번호 (대용량 파일 모드가 아니면 0)
+func (sp *SyncProtocol) lineBase() int {
+	if sp.large == nil {
+		return 0
+	}
+	return sp.large.top
+}
+
+// totalLines: 파일 전체 라인 수 (대용량 파일 모드면 지금까지 색인된 수)
+func (sp *SyncProtocol) totalLines() int {
+	if sp.large == nil {
+		return sp.LineCount()
+	}
+	return sp.large.index.count()
+}
+
+// rejectsCommand: 대용량 파일 모드에서 막는 명령 (문서나 파일을 바꾸는 명령)
+func (lf *largeFile) rejectsCommand(cmd commander.Command) bool {
+	switch cmd.Code {
+	case commander.CmdSave, commander.CmdSaveWithEncoding, commander.CmdReopenWithEncoding,
+		commander.CmdSubstitute, commander.CmdUndo, commander.CmdRedo:
+		return true
+	}
+	return opBuilders[cmd.Code].Edit
+}
+
+// followCursor: 커서가 창 끝에서 한 화면 안으로 들어오면 커서 주변으로 창을 다시 만듦
+// 명령 전후에 불러서 커서가 창 끝에 막히지 않게 함
+func (sp *SyncProtocol) followCursor() {
+	if sp.large == nil {
+		return
+	}
+	line := sp.cursorLineIndex()
+	if line < 0 {
+		return
+	}
+	margin := sp.visibleLineCount()
+	nearTop := line < margin && sp.large.top > 0
+	nearEnd := line >= sp.LineCount()-margin && sp.large.top+sp.LineCount() < sp.large.index.count()
+	if nearTop || nearEnd {
+		sp.showLine(sp.large.top+line, sp.cursor.currentCharInset)
+	}
+}
+
+// showLine: 커서를 파일의 line번째 줄 col로 옮김. 창 밖이거나 창 끝 근처면 그 주변으로 창을 다시 만듦
+// 화면 맨 위 줄은 가능하면 파일 기준으로 그대로 둠
+func (sp *SyncProtocol) showLine(line, col int) {
+	lf := sp.large
+	line = min(max(line, 0), max(lf.index.count()-1, 0))
+	visible := sp.visibleLineCount()
+	viewTop := lf.top + sp.viewTop
+	end := lf.top + sp.LineCount()
+	if line >= end || (line < lf.top+visible && lf.top > 0) || (line >= end-visible && end < lf.index.count()) {
+		sp.materialize(max(line-visible*windowScreens, 0), visible*(2*windowScreens+1))
+	}
+	node, found := sp.doc.data.findNode(uint(max(line-lf.top, 0)))
+	if !found {
+		// 아직 끝을 아는 줄이 없음 (첫 줄바꿈 전)
+		return
+	}
+	sp.cursor.currentLineBuffer = node.LineBuffer
+	sp.cursor.currentCharInset = min(max(col, 0), node.PieceTable.Length())
+	sp.viewTop = min(max(viewTop-lf.top, 0), sp.LineCount()-1)
+	sp.refreshCurrentLine()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+}
+
+// materialize: 파일의 [top, top+n) 줄로 노드 리스트를 새로 만들고 그림 (변경 이벤트는 없음)
+// 이전 라인버퍼에 그려진 커서/강조는 버림. 커서는 호출한 쪽에서 새 노드로 옮겨야 함
+func (sp *SyncProtocol) materialize(top, n int) {
+	lines := sp.large.index.lines(top, n)
+	if len(lines) == 0 {
+		return
+	}
+	sp.cursor.visible = false
+	sp.cursor.capturedBuffer = nil
+	sp.highlightedNode = nil
+	sp.mark = nil
+	sp.selectionSpans = nil
+	if sp.search != nil {
+		sp.search.byNode = nil
+		sp.search.stale = true
+	}
+	sp.large.top = top
+	sp.doc.setStore(syncDataBytes(lines))
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		sp.syncNode(sn)
+	})
+}
+
+// cursorFilePos: 커서의 파일 기준 위치 (라인은 파일 라인 번호)
+func (sp *SyncProtocol) cursorFilePos() Position {
+	return Position{Line: sp.lineBase() + sp.cursorLineIndex(), Col: sp.cursor.currentCharInset}
+}
+
+// findLarge: 대용량 파일 모드의 검색. 색인된 부분의 바이트에서 바로 찾고 그 줄로 창을 옮김
+// from 이후(inclusive면 from 포함, !forward면 from 이전)의 매치로 가고 끝에서는 반대쪽으로 넘어감
+func (sp *SyncProtocol) findLarge(from Position, forward, inclusive bool) (found, wrapped bool) {
+	query := []byte(sp.search.query)
+	index := sp.large.index
+	limit := index.searchLimit()
+	if len(query) == 0 || from.Line >= index.count() {
+		return false, false
+	}
+	start := index.lineStart(from.Line)
+	text := index.lines(from.Line, 1)[0]
+	off := min(start+len(runePrefix(text, from.Col)), limit)
+
+	match := -1
+	if forward {
+		if !inclusive && off < limit {
+			_, size := utf8.DecodeRune(index.data[off:])
+			off += size
+		}
+		if i := bytes.Index(index.data[off:limit], query); i >= 0 {
+			match = off + i
+		} else if i := bytes.Index(index.data[:limit], query); i >= 0 {
+			match, wrapped = i, true
+		}
+	} else {
+		// off 앞에서 시작하는 매치만 (끝이 off를 넘어도 됨)
+		if i := bytes.LastIndex(index.data[:min(off+len(query)-1, limit)], query); i >= 0 {
+			match = i
+		} else if i := bytes.LastIndex(index.data[:limit], query); i >= 0 {
+			match, wrapped = i, true
+		}
+	}
+	if match < 0 {
+		return false, false
+	}
+	line := index.lineAt(match)
+	col := utf8.RuneCount(index.data[index.lineStart(line):match])
+	sp.showLine(line, col)
+	return true, wrapped
+}
+
+// runePrefix: text의 앞 n글자
+func runePrefix(text []byte, n int) []byte {
+	off := 0
+	for i := 0; i < n && off < len(text); i++ {
+		_, size := utf8.DecodeRune(text[off:])
+		off += size
+	}
+	return text[:off]
+}
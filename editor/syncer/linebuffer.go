@@ -15,7 +15,7 @@ func (sp *SyncProtocol) NewLineBuffer() *LineBuffer {
 	return &LineBuffer{data: data}
 }
 
-// FlushLineBuffer: collects all LineBuffer data from nodes into a 2D array for rendering
+// FlushLineBuffer: collects the visible LineBuffer data into a 2D array for rendering
 func (sp *SyncProtocol) FlushLineBuffer() [][]uint32 {
 
 	lineBuffers := [][]uint32{}
@@ -26,69 +26,172 @@ func (sp *SyncProtocol) FlushLineBuffer() [][]uint32 {
 		sp.cursor.ClearCursor(sp)
 	}
 
-	head := sp.syncData.head
+	head := sp.doc.data.head
 	if head == nil {
-		println("emtpy list")
 		return lineBuffers
 	}
 
-	// Check if syncData exists and has nodes
-	if sp.syncData != nil && sp.syncData.head != nil {
-		// Iterate through all nodes in the linked list
-		sp.syncData.ForEach(func(node *SyncNode) {
-			if node.LineBuffer != nil {
-				// Create a copy of the line data to avoid reference issues
-				lineData := make([]uint32, len(node.LineBuffer.data))
-				copy(lineData, node.LineBuffer.data)
-
-				// Add this line to our collection
-				lineBuffers = append(lineBuffers, lineData)
-			}
-		})
+	// 뷰포트 범위 [viewTop, viewTop+visible)의 노드만 찾아가서 화면에 보냄 (나머지 노드는 건드리지 않음)
+	// 줄 번호는 파일 기준 (대용량 파일 모드면 창의 첫 줄 번호를 더함)
+	base := sp.lineBase()
+	cursorIndex := base + sp.cursorLineIndex()
+	node, _ := sp.doc.data.findNode(uint(sp.viewTop))
+	for index := sp.viewTop; node != nil && index < sp.viewTop+sp.visibleLineCount(); index++ {
+		if node.LineBuffer != nil {
+			// 커서/강조가 그려진 원본을 건드리지 않도록 복사본을 보냄
+			lineData := make([]uint32, len(node.LineBuffer.data))
+			copy(lineData, node.LineBuffer.data)
+			// 줄 번호는 복사본에만 그림 (상대 번호는 커서 이동마다 바뀜)
+			sp.drawGutter(&LineBuffer{data: lineData}, base+index, cursorIndex)
+			lineBuffers = append(lineBuffers, lineData)
+		}
+		node = node.next
 	}
 
 	return lineBuffers
 }
 
 func (sp *SyncProtocol) ReflectLine(l *LineBuffer, text string) {
+	sp.reflectLine(l, text, sp.bgColor, nil)
+}
+
+// reflectLine: 배경색을 지정해서 그림 (현재 라인 강조 등)
+// highlights 범위의 글자는 검색 매치 색을 배경으로 깔아줌
+func (sp *SyncProtocol) reflectLine(l *LineBuffer, text string, bg uint32, highlights []span) {
 	//배경색 칠하기
 	for i := range l.data {
-		l.data[i] = sp.bgColor
+		l.data[i] = bg
 	}
-	drawX := 0
-	yOffset := (sp.LineHeight - glp.GlyphHeight) / 2 // 수직 중앙
+	drawX := sp.gutterWidth // 거터 자리는 비워둠
+	col := 0
+	yOffset := (sp.LineHeight - sp.glyphHeight()) / 2 // 수직 중앙
+	inset := 0
 	for _, ch := range text {
-		glyph, ok := glp.GlyphMap[ch]
-		if !ok {
-			glyph = glp.Glyph{}
+		cells := 1
+		if ch == '\t' {
+			cells = sp.nextTabStop(col) - col
+		}
+		if inSpans(highlights, inset) {
+			sp.fillCells(l, drawX, cells*sp.glyphWidth(), sp.matchColor())
+		}
+		inset++
+		if ch == '\t' {
+			// 탭은 다음 탭 위치까지 빈칸
+			drawX += cells * sp.glyphWidth()
+			col += cells
+		} else {
+			sp.drawGlyphToLine(l, drawX, yOffset, glyphFor(ch), sp.fgColor)
+			drawX += sp.glyphWidth()
+			col++
 		}
-		sp.drawGlyphToLine(l, drawX, yOffset, glyph, sp.fgColor)
-		drawX += glp.GlyphWidth
 		if drawX >= sp.screenWidth {
 			break
 		}
 	}
 }
 
-// drawGlyphToLine: 한 줄(16*width)의 픽셀에 글리프를 배치
+// inSpans: 인셋이 범위들 중 하나에 들어가는지
+func inSpans(spans []span, inset int) bool {
+	for _, s := range spans {
+		if inset >= s.start && inset < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// fillCells: 라인 전체 높이로 [x, x+width) 영역을 색칠
+func (sp *SyncProtocol) fillCells(l *LineBuffer, x, width int, color uint32) {
+	for row := 0; row < sp.LineHeight; row++ {
+		for col := max(x, 0); col < x+width && col < sp.screenWidth; col++ {
+			l.data[row*sp.screenWidth+col] = color
+		}
+	}
+}
+
+// glyphFor: 글리프가 없는 글자는 빈칸
+func glyphFor(ch rune) glp.Glyph {
+	glyph, ok := glp.GlyphMap[ch]
+	if !ok {
+		return glp.Glyph{}
+	}
+	return glyph
+}
+
+// nextTabStop: col 다음의 탭 위치
+func (sp *SyncProtocol) nextTabStop(col int) int {
+	return (col/sp.tabWidth + 1) * sp.tabWidth
+}
+
+// visualColumn: text의 inset번째 글자가 화면상 몇 번째 칸인지 (탭 반영)
+func (sp *SyncProtocol) visualColumn(text string, inset int) int {
+	col := 0
+	i := 0
+	for _, ch := range text {
+		if i >= inset {
+			break
+		}
+		if ch == '\t' {
+			col = sp.nextTabStop(col)
+		} else {
+			col++
+		}
+		i++
+	}
+	// 라인 끝을 넘는 인셋은 그대로 한 칸씩
+	return col + max(inset-i, 0)
+}
+
+// SetTabWidth: 탭 폭 변경 후 전체 다시 그림
+func (sp *SyncProtocol) SetTabWidth(width int) {
+	if width < 1 {
+		width = 1
+	}
+	sp.tabWidth = width
+	sp.rerenderAll()
+}
+
+// TabWidth: 현재 탭 폭
+func (sp *SyncProtocol) TabWidth() int {
+	return sp.tabWidth
+}
+
+// rerenderAll: 모든 노드의 라인버퍼를 다시 그림
+// 커서가 백업해 둔 픽셀도 옛날 것이라 먼저 지우고 다시 그린다.
+func (sp *SyncProtocol) rerenderAll() {
+	wasVisible := sp.cursor.visible
+	sp.cursor.ClearCursor(sp)
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		sp.syncNode(sn)
+	})
+	if wasVisible {
+		sp.cursor.CusorDrawOn(sp)
+	}
+}
+
+// drawGlyphToLine: 한 줄(LineHeight*width)의 픽셀에 글리프를 배치
 func (sp *SyncProtocol) drawGlyphToLine(l *LineBuffer, startX, startY int, glyph glp.Glyph, fg uint32) {
-	// linePixels는 높이=16, 폭=width
-	for row := 0; row < glp.GlyphHeight; row++ {
-		lineBits := glyph[row]
-		for col := 0; col < glp.GlyphWidth; col++ {
-			mask := byte(1 << (7 - col))
+	sp.drawGlyph(l.data, sp.LineHeight, sp.scale, startX, startY, glyph, fg)
+}
+
+// drawGlyph: 폭=screenWidth, 높이=height인 픽셀 배열에 글리프를 배치
+// 배율만큼 비트 하나를 scale*scale 블록으로 찍음 (nearest-neighbour)
+func (sp *SyncProtocol) drawGlyph(pixels []uint32, height, scale, startX, startY int, glyph glp.Glyph, fg uint32) {
+	for row := 0; row < glp.GlyphHeight*scale; row++ {
+		lineBits := glyph[row/scale]
+		for col := 0; col < glp.GlyphWidth*scale; col++ {
+			mask := byte(1 << (7 - col/scale))
 			if (byte(lineBits) & mask) != 0 {
 				px := startX + col
 				py := startY + row
 				if px < 0 || px >= sp.screenWidth {
 					continue
 				}
-				if py < 0 || py >= sp.LineHeight {
+				if py < 0 || py >= height {
 					continue
 				}
-				// index in linePixels = py*width + px
-				idx := py*sp.screenWidth + px
-				l.data[idx] = fg
+				// index in pixels = py*width + px
+				pixels[py*sp.screenWidth+px] = fg
 			}
 		}
 	}
@@ -0,0 +1,26 @@
+package syncer
+
+import (
+	"go_editor/editor/logging"
+	"log/slog"
+)
+
+// defaultLogger: 새로 만드는 SyncProtocol의 로거 (기본은 출력 없음)
+// 로드 중의 로그도 여기로 가므로 생성 전에 정해야 함
+var defaultLogger = logging.Discard()
+
+// SetDefaultLogger: 이후 생성되는 SyncProtocol들이 쓸 로거
+func SetDefaultLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Discard()
+	}
+	defaultLogger = l
+}
+
+// SetLogger: 이 문서의 로거 교체
+func (sp *SyncProtocol) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Discard()
+	}
+	sp.logger = l
+}
@@ -1,116 +1,172 @@
 package syncer
 
-import "fmt"
+import (
+	"fmt"
+	"go_editor/editor/commander"
+	"strings"
+	"unicode/utf8"
+)
 
 // -----------------------------------
-// opSequences 구조체
-//   - head: 연결 리스트의 시작 노드
-//   - tail: 연결 리스트의 마지막 노드
+// Op: 연산 하나
+//   - Info: 종류/코드/대상 노드 (기록, 롤백 대상 수집에 사용)
+//   - Execute: 실제 동작. 가드는 빌드 단계에서 처리하고 여기선 믿고 실행
 //
+// 외부 패키지(플러그인, 테스트, 매크로)도 Op를 구현하고 RegisterOpKind/RegisterOpBuilder로
+// op.go를 고치지 않고 연산을 추가할 수 있다. Execute에서 쓰는 접근자는 opapi.go
 // -----------------------------------
-type opSequences struct {
-	head opSequence
-	tail opSequence
+type Op interface {
+	Info() OpInfo
+	Execute(sp *SyncProtocol)
 }
 
-func NewOpSequences() *opSequences {
-	return &opSequences{}
+type OpInfo struct {
+	Kind   OpKind
+	Code   int
+	Target *SyncNode // 대상 노드 (커서 기준 연산이면 nil)
+	Char   rune
+	Text   string // 문자열 연산 (OpTypeText)
 }
 
-// [ADDED] ExecuteAll 메서드 예시:
-//
-//	모든 노드를 순회하면서 각 노드의 executeOp()를 실행하는 예시입니다.
-//	필요에 따라 이름과 사용 방식을 조정하세요.
-func (ops *opSequences) ExecuteAll(sp *SyncProtocol) {
-	current := ops.head
-	for current != nil {
-		current.executeOp(sp)
-		current = current.next()
-	}
-	println("모든 노드의 executeOp() 실행 완료")
+// String: 디버그 기록용 어셈블리 형태, 대상 라인은 빼고 (예: CURSOR up, TEXT insert 'a')
+func (info OpInfo) String() string {
+	return formatInstruction(info, "")
 }
 
-// ForEach() : 모든 노드를 순회하며, 각 노드의 opInfo를 fn으로 전달
-func (ops *opSequences) ForEach(fn func(info opInfo)) {
-	current := ops.head
-	for current != nil {
-		fn(current.op())
-		current = current.next()
+func codeName(names []string, code int) string {
+	if code < 0 || code >= len(names) {
+		return fmt.Sprintf("unknown%d", code)
 	}
+	return names[code]
 }
 
-// Append() : 새로운 opSequence(노드 체인)를 연결 리스트 끝에 붙인다.
-func (ops *opSequences) Append(seq opSequence) {
-	if seq == nil {
-		return
+type OpKind int
+
+const (
+	OpKindNodeGroup OpKind = iota
+	OpKindNodeText
+	OpKindSync
+	OpKindCursor
+)
+
+type opKindInfo struct {
+	mnemonic  string                   // 어셈블리 이름 (GROUP, TEXT, ...)
+	codeNames []string                 // 코드 순서대로의 이름
+	decode    func(OpInfo) (Op, error) // 텍스트에서 다시 만들 때 (없으면 파싱 불가)
+}
+
+// 등록된 op 종류 (기본 4종 + RegisterOpKind로 추가된 것)
+var opKinds = map[OpKind]opKindInfo{
+	OpKindNodeGroup: {"GROUP", []string{"insert", "delete", "slice", "modify", "merge", "hold"}, decodeNodeGroup},
+	OpKindNodeText:  {"TEXT", []string{"insert", "delete", "hold", "type"}, decodeNodeText},
+	OpKindSync:      {"SYNC", []string{"inserted", "sliced", "modified", "deleted", "hold"}, decodeSync},
+	OpKindCursor: {"CURSOR", []string{"up", "down", "left", "right", "upLeftStart", "upRightEnd",
+		"downLeftStart", "downRightEnd", "leftStart", "rightEnd", "hold"}, decodeCursor},
+}
+
+// RegisterOpKind: 새 op 종류 등록. name은 대문자로 바꿔 어셈블리 이름으로 쓰고,
+// codeNames는 코드 순서대로의 이름. 패키지 init에서 호출할 것 (동시 호출은 고려하지 않음)
+func RegisterOpKind(name string, codeNames ...string) OpKind {
+	kind := OpKind(len(opKinds))
+	for _, exists := opKinds[kind]; exists; _, exists = opKinds[kind] {
+		kind++
 	}
+	opKinds[kind] = opKindInfo{mnemonic: strings.ToUpper(name), codeNames: codeNames}
+	return kind
+}
 
-	// 1) 연결 리스트가 비어있다면 (head == nil)
-	//    head와 tail을 seq로 설정
-	if ops.head == nil {
-		ops.head = seq
-		ops.tail = getTail(seq)
+// SetOpDecoder: 등록한 종류를 ParseOps로 읽을 수 있게 디코더 지정
+func SetOpDecoder(kind OpKind, decode func(OpInfo) (Op, error)) {
+	k, ok := opKinds[kind]
+	if !ok {
 		return
 	}
+	k.decode = decode
+	opKinds[kind] = k
+}
 
-	// 2) 비어있지 않다면 tail에 seq를 연결
-	//   - tail.nextOp = seq
-	//   - 그 다음, tail을 새로 추가된 체인의 마지막 노드로 갱신
-	linkTail(ops.tail, seq)
-	ops.tail = getTail(seq)
+// -----------------------------------
+// OpSequences: 한 명령으로 실행할 op 목록 (순서대로 실행)
+// -----------------------------------
+type OpSequences struct {
+	ops []Op
+}
+
+func NewOpSequences() *OpSequences {
+	return &OpSequences{}
+}
+
+// ExecuteAll: 모든 op를 순서대로 실행
+// 트랜잭션으로 실행: op가 패닉하거나 불변식을 깨면 실행 전으로 롤백하고 *InvariantError 리턴
+// 변경 이벤트는 성공했을 때만 구독자에게 전달
+func (ops *OpSequences) ExecuteAll(sp *SyncProtocol) error {
+	_, err := ops.execute(sp)
+	return err
 }
 
-// getTail() : 전달받은 시퀀스(seq)의 마지막 노드를 찾아 반환
-func getTail(start opSequence) opSequence {
-	cur := start
-	for cur != nil {
-		if cur.next() == nil {
-			return cur
+// execute: ExecuteAll과 같고, 성공하면 되돌리기에 쓸 트랜잭션을 돌려줌
+func (ops *OpSequences) execute(sp *SyncProtocol) (*opTx, error) {
+	tx := beginTx(sp, ops)
+	failedAt, violations := ops.executeChecked(sp, tx)
+	if len(violations) > 0 {
+		tx.rollback(sp)
+		sp.doc.discardChanges()
+		return nil, &InvariantError{
+			Violations: violations,
+			Trace:      ops.trace(sp, failedAt),
+			RolledBack: true,
 		}
-		cur = cur.next()
 	}
-	return nil
-}
-
-// linkTail() : tail 노드에 seq 노드를 연결
-func linkTail(tail, seq opSequence) {
-	switch t := tail.(type) {
-	case *OpNodeGroup:
-		t.nextOp = seq
-	case *OpNodeText:
-		t.nextOp = seq
-	case *OpSync:
-		t.nextOp = seq
-	case *OpCursor:
-		t.nextOp = seq
+	sp.doc.publishChanges()
+	return tx, nil
+}
+
+// ForEach: 모든 op를 순서대로 fn에 전달
+func (ops *OpSequences) ForEach(fn func(op Op)) {
+	for _, op := range ops.ops {
+		fn(op)
 	}
 }
 
-// -----------------------------------
-// 아래부터는 기존 코드 (인터페이스, 노드들) 그대로 사용
-// -----------------------------------
+// Append: op들을 끝에 붙인다 (nil은 무시)
+func (ops *OpSequences) Append(seq ...Op) {
+	for _, op := range seq {
+		if op != nil {
+			ops.ops = append(ops.ops, op)
+		}
+	}
+}
 
-type opSequence interface {
-	op() opInfo
-	next() opSequence
-	executeOp(*SyncProtocol)
+// Len: op 개수
+func (ops *OpSequences) Len() int {
+	return len(ops.ops)
 }
 
-type opInfo struct {
-	opKind     opKind
-	opCode     int
-	targetNode *SyncNode
-	char       rune
+// -----------------------------------
+// OpBuilder: 명령 코드 -> op 목록 빌더
+//   - Build: 가드를 처리해서 op 목록 생성. false면 실행하지 않고 종료 신호
+//   - Edit: 문서를 바꾸는 명령인지 (되돌리기 기록, dirty 표시)
+//
+// -----------------------------------
+type OpBuilder struct {
+	Build func(sp *SyncProtocol, cmd commander.Command) (*OpSequences, bool)
+	Edit  bool
 }
 
-type opKind int
+var opBuilders = map[commander.CommandCode]OpBuilder{}
 
-const (
-	OpKindNodeGroup opKind = iota
-	OpKindNodeText
-	OpKindSync
-	OpKindCursor
-)
+// RegisterOpBuilder: 명령 코드의 빌더 등록 (기존 빌더는 교체). 이전 빌더와 등록 여부 리턴
+// 패키지 init에서 호출할 것 (동시 호출은 고려하지 않음)
+func RegisterOpBuilder(code commander.CommandCode, b OpBuilder) (prev OpBuilder, existed bool) {
+	prev, existed = opBuilders[code]
+	opBuilders[code] = b
+	return prev, existed
+}
+
+// UnregisterOpBuilder: 빌더 제거
+func UnregisterOpBuilder(code commander.CommandCode) {
+	delete(opBuilders, code)
+}
 
 // -----------------------------------
 // NodeGroup 관련 상수 & 노드
@@ -130,52 +186,75 @@ const (
 type OpNodeGroup struct {
 	opCode    NodeGroupOpCode
 	startNode *SyncNode
-	nextOp    opSequence
-}
-
-func (ng *OpNodeGroup) op() opInfo {
-	return opInfo{
-		opKind:     OpKindNodeGroup,
-		opCode:     int(ng.opCode),
-		targetNode: ng.startNode,
-		char:       ' ', // NodeGroup에서는 char 사용 안 함
-	}
 }
-func (ng *OpNodeGroup) next() opSequence {
 
-	if ng.nextOp == nil {
-		return nil
+func (ng *OpNodeGroup) Info() OpInfo {
+	return OpInfo{
+		Kind:   OpKindNodeGroup,
+		Code:   int(ng.opCode),
+		Target: ng.startNode,
+		Char:   ' ', // NodeGroup에서는 char 사용 안 함
 	}
-	return ng.nextOp
 }
 
 // [ADDED] executeOp(): opCode에 따른 실제 동작을 switch로 분기
-func (ng *OpNodeGroup) executeOp(sp *SyncProtocol) {
-	sd := sp.syncData
+func (ng *OpNodeGroup) Execute(sp *SyncProtocol) {
+	sd := sp.doc.data
 	_, charInset := sp.cursor.GetCoordinate()
 
+	// 이벤트의 라인 번호/텍스트는 구독자가 있을 때만 만듦 (findOrder, String이 O(n))
+	observed := sp.doc.observed()
 	switch ng.opCode {
 	case OpInserNodeToGroup:
-		fmt.Println("NodeGroup -> InsertNodeToGroup 실행")
+		// 대상 노드 앞에 삽입 (nil이면 맨 앞)
+		ev := ChangeEvent{Kind: NodeInserted}
+		if observed {
+			ev.Line, ev.After = max(sd.findOrder(ng.startNode), 0), []string{""}
+		}
 		sd.insertByPtr(ng.startNode, string(""))
+		sp.EmitChange(ev)
 	case OpDeletNodeFromGroup:
-		fmt.Println("NodeGroup -> DeleteNodeFromGroup 실행")
+		ev := ChangeEvent{Kind: NodeDeleted}
+		if observed {
+			ev.Line, ev.Before = sd.findOrder(ng.startNode), []string{ng.startNode.PieceTable.String()}
+		}
 		sd.deleteByPtr(ng.startNode)
+		sp.EmitChange(ev)
 	case OpSliceNodeAtGroup:
-		fmt.Println("NodeGroup -> SliceNodeAtGroup 실행")
-		println("시작 노드 번호", sp.syncData.findOrder(ng.startNode))
+		next := ng.startNode.next
+		ev := ChangeEvent{Kind: NodeSliced}
+		if observed {
+			ev.Before = []string{ng.startNode.PieceTable.String()}
+		}
 		sd.sliceByPtr(ng.startNode, uint(charInset))
+		if ng.startNode.next == next {
+			break // 나눌 수 없는 위치
+		}
+		if observed {
+			ev.Line = sd.findOrder(ng.startNode)
+			ev.After = []string{ng.startNode.PieceTable.String(), ng.startNode.next.PieceTable.String()}
+		}
+		sp.EmitChange(ev)
 	case OpMergeNodesInGroup:
-		fmt.Println("NodeGroup -> MergeNodesInGroup 실행")
-		sd.mergeNodeByPtr(ng.startNode, ng.startNode.next)
+		next := ng.startNode.next
+		if next == nil {
+			break
+		}
+		ev := ChangeEvent{Kind: NodeMerged}
+		if observed {
+			ev.Before = []string{ng.startNode.PieceTable.String(), next.PieceTable.String()}
+		}
+		sd.mergeNodeByPtr(ng.startNode, next)
+		if observed {
+			ev.Line, ev.After = sd.findOrder(ng.startNode), []string{ng.startNode.PieceTable.String()}
+		}
+		sp.EmitChange(ev)
 	case OpModifyNodeOnGroup:
-		fmt.Println("NodeGroup -> ModifyNodeOnGroup 실행")
 		//여기선 일단 홀딩
 	case OpHoldAllGroup:
 		//여기도 일단 홀드
-		fmt.Println("NodeGroup -> HoldAllGroup 실행")
 	default:
-		fmt.Println("NodeGroup -> 알 수 없는 opCode")
+		sp.logger.Warn("알 수 없는 op 코드", "op", ng.Info())
 	}
 }
 func NewOpNodeGroup(code NodeGroupOpCode, target *SyncNode) *OpNodeGroup {
@@ -194,50 +273,64 @@ const (
 	OpInsertRune NodeTextOpCode = iota
 	OpDeleteRune
 	OpHoldRune
+	OpTypeText // 문자열 삽입 후 커서를 그만큼 오른쪽으로 (InsertRune+RightCursor 묶음)
 )
 
 // OpNodeText: opSequence 구현체 (NodeText 연산)
 type OpNodeText struct {
 	opCode NodeTextOpCode
 	char   rune
-	nextOp opSequence
+	text   string // OpTypeText에서만 사용
 }
 
-func (nt *OpNodeText) op() opInfo {
-	return opInfo{
-		opKind:     OpKindNodeText,
-		opCode:     int(nt.opCode),
-		targetNode: nil, // 텍스트 관련이라 targetNode는 사용 안 함
-		char:       nt.char,
+func (nt *OpNodeText) Info() OpInfo {
+	return OpInfo{
+		Kind:   OpKindNodeText,
+		Code:   int(nt.opCode),
+		Target: nil, // 텍스트 관련이라 targetNode는 사용 안 함
+		Char:   nt.char,
+		Text:   nt.text,
 	}
 }
-func (nt *OpNodeText) next() opSequence {
-	if nt.nextOp == nil {
-		return nil
-	}
-	return nt.nextOp
-}
 
 // [ADDED] executeOp()
-func (nt *OpNodeText) executeOp(sp *SyncProtocol) {
+func (nt *OpNodeText) Execute(sp *SyncProtocol) {
 	lineBuffer, charInset := sp.cursor.GetCoordinate()
-	syncNode := sp.syncData.findSyncNodeByLineBuffer(lineBuffer)
+	syncNode := sp.doc.data.findSyncNodeByLineBuffer(lineBuffer)
+	// 구독자가 없으면 라인을 문자열로 만들지 않음 (글자마다 불리는 경로)
+	observed := sp.doc.observed()
+	var before string
+	if observed {
+		before = syncNode.PieceTable.String()
+	}
 	switch nt.opCode {
 	case OpInsertRune:
-		fmt.Printf("NodeText -> InsertRune(%c)\n", nt.char)
 		syncNode.PieceTable.InsertRune(charInset, nt.char)
 	case OpDeleteRune:
-		fmt.Printf("NodeText -> DeleteRune(%c)\n", nt.char)
 		//여기선 최대한 간결한 동장을 지향함
 		// 가드클로스는 빌딩때 다 처리
-		println(charInset, "의 문자 삭제함")
-		println("전처리 전", syncNode.PieceTable.String())
 		syncNode.PieceTable.DeleteRune(charInset)
-		println("전처리 이후", syncNode.PieceTable.String())
 	case OpHoldRune:
-		fmt.Printf("NodeText -> HoldRune(%c)\n", nt.char)
+		// 아무것도 안 함
+		return
+	case OpTypeText:
+		syncNode.PieceTable.Insert(charInset, nt.text)
+		sp.cursor.currentCharInset += utf8.RuneCountInString(nt.text)
 	default:
-		fmt.Println("NodeText -> 알 수 없는 opCode")
+		sp.logger.Warn("알 수 없는 op 코드", "op", nt.Info())
+		return
+	}
+	if !observed {
+		sp.EmitChange(ChangeEvent{Kind: NodeModified})
+		return
+	}
+	if after := syncNode.PieceTable.String(); after != before {
+		sp.EmitChange(ChangeEvent{
+			Kind:   NodeModified,
+			Line:   sp.doc.data.findOrder(syncNode),
+			Before: []string{before},
+			After:  []string{after},
+		})
 	}
 }
 
@@ -249,6 +342,14 @@ func NewOpNodeText(code NodeTextOpCode, ch rune) *OpNodeText {
 	}
 }
 
+// NewOpTypeText: 연속 입력을 묶은 문자열 삽입 (최적화 단계에서 만듦)
+func NewOpTypeText(text string) *OpNodeText {
+	return &OpNodeText{
+		opCode: OpTypeText,
+		text:   text,
+	}
+}
+
 // -----------------------------------
 // SyncOpCode 관련 상수 & 노드
 // -----------------------------------
@@ -266,31 +367,22 @@ const (
 type OpSync struct {
 	opCode    SyncOpCode
 	startNode *SyncNode
-
-	nextOp opSequence
 }
 
-func (so *OpSync) op() opInfo {
-	return opInfo{
-		opKind: OpKindSync,
-		opCode: int(so.opCode),
+func (so *OpSync) Info() OpInfo {
+	return OpInfo{
+		Kind: OpKindSync,
+		Code: int(so.opCode),
 
-		targetNode: so.startNode,
-		char:       ' ', // SyncOp에서는 char 사용 안 함
-	}
-}
-func (so *OpSync) next() opSequence {
-	if so.nextOp == nil {
-		return nil
+		Target: so.startNode,
+		Char:   ' ', // SyncOp에서는 char 사용 안 함
 	}
-	return so.nextOp
 }
 
 // [ADDED] executeOp()
-func (so *OpSync) executeOp(sp *SyncProtocol) {
+func (so *OpSync) Execute(sp *SyncProtocol) {
 	switch so.opCode {
 	case OpNodeInsertedSync:
-		fmt.Println("Sync -> NodeInsertedSync 실행")
 		//스타트와 스타트의 prev (단 prev는 nil일수도 있다.)
 		prevNode := so.startNode.prev
 		if prevNode != nil {
@@ -298,7 +390,6 @@ func (so *OpSync) executeOp(sp *SyncProtocol) {
 		}
 		sp.syncNode(so.startNode)
 	case OpNodeSlicedSync:
-		fmt.Println("Sync -> NodeSlicedSync 실행")
 		//스타트와 스타트의 next (이 경우엔 둘다 반드시 존재.)
 		nextNode := so.startNode.next
 
@@ -307,16 +398,14 @@ func (so *OpSync) executeOp(sp *SyncProtocol) {
 		}
 		sp.syncNode(so.startNode)
 	case OpNodeModifiedSync:
-		fmt.Println("Sync -> NodeModifiedSync 실행")
 		//하나만 싱크
 		sp.syncNode(so.startNode)
 	case OpNodeDeletedSync:
-		fmt.Println("Sync -> NodeDeletedSync 실행")
 		//일단 암것도 안함
 	case OpNodeHoldSync:
-		fmt.Println("Sync -> NodeHoldSync 실행")
+		// 아무것도 안 함
 	default:
-		fmt.Println("Sync -> 알 수 없는 opCode")
+		sp.logger.Warn("알 수 없는 op 코드", "op", so.Info())
 	}
 }
 
@@ -350,88 +439,68 @@ const (
 // OpCursor: opSequence 구현체 (Cursor 연산)
 type OpCursor struct {
 	opCode CursorOpCode
-
-	nextOp opSequence
 }
 
-func (co *OpCursor) op() opInfo {
-	return opInfo{
-		opKind: OpKindCursor,
-		opCode: int(co.opCode),
+func (co *OpCursor) Info() OpInfo {
+	return OpInfo{
+		Kind: OpKindCursor,
+		Code: int(co.opCode),
 
-		targetNode: nil, // 커서 이동이니 targetNode는 사용 안 함
-		char:       ' ',
-	}
-}
-func (co *OpCursor) next() opSequence {
-	if co.nextOp == nil {
-		return nil
+		Target: nil, // 커서 이동이니 targetNode는 사용 안 함
+		Char:   ' ',
 	}
-	return co.nextOp
 }
 
 // [ADDED] executeOp()
-func (co *OpCursor) executeOp(sp *SyncProtocol) {
+func (co *OpCursor) Execute(sp *SyncProtocol) {
 	c := sp.cursor
 	cuerrentLine, currentCharInset := c.GetCoordinate()
-	currentNode := sp.syncData.findSyncNodeByLineBuffer(cuerrentLine)
+	currentNode := sp.doc.data.findSyncNodeByLineBuffer(cuerrentLine)
 	switch co.opCode {
 	case OpUpCursor:
-		fmt.Println("Cursor -> UpCursor 실행")
 		// 가드 클로스는 빌딩때 처리함
 		//라인만 한칸 이동
 		prevNode := currentNode.prev
 		c.currentLineBuffer = prevNode.LineBuffer
-		// 라인 길이를 최대로 삼음
-		maxInset := max(currentCharInset, prevNode.PieceTable.Length())
-		c.currentCharInset = maxInset
+		// 윗 라인이 더 짧으면 라인 끝으로
+		c.currentCharInset = min(currentCharInset, prevNode.PieceTable.Length())
 	case OpDownCursor:
-		fmt.Println("Cursor -> DownCursor 실행")
 		// 가드 클로스는 빌딩때 처리함
 		//라인만 한칸 이동
 		nextNode := currentNode.next
 		c.currentLineBuffer = nextNode.LineBuffer
-		// 라인 길이를 최대로 삼음
-		maxInset := max(currentCharInset, nextNode.PieceTable.Length())
-		c.currentCharInset = maxInset
+		// 아랫 라인이 더 짧으면 라인 끝으로
+		c.currentCharInset = min(currentCharInset, nextNode.PieceTable.Length())
 	case OpLeftCursor:
-		fmt.Println("Cursor -> LeftCursor 실행")
 		// 가드 클로스는 빌딩때 처리함
 		c.currentCharInset = max(c.currentCharInset-1, 0)
 	case OpRightCursor:
-		fmt.Println("Cursor -> RightCursor 실행")
 		// 가드 클로스는 빌딩때 처리함
 		c.currentCharInset += 1
 	case OpUpLeftStartCursor:
-		fmt.Println("Cursor -> UpLeftStartCursor 실행")
 		prevNode := currentNode.prev
 		c.currentLineBuffer = prevNode.LineBuffer
 		c.currentCharInset = 0
 	case OpUpRightEndCursor:
-		fmt.Println("Cursor -> UpRightEndCursor 실행")
 		prevNode := currentNode.prev
 		c.currentLineBuffer = prevNode.LineBuffer
 		c.currentCharInset = prevNode.PieceTable.Length()
 	case OpDownLeftStartCursor:
-		fmt.Println("Cursor -> DownLeftStartCursor 실행")
 		nextNode := currentNode.next
 		c.currentLineBuffer = nextNode.LineBuffer
 		c.currentCharInset = 0
 	case OpDownRightEndCursor:
-		fmt.Println("Cursor -> DownRightEndCursor 실행")
 		nextNode := currentNode.next
 		c.currentLineBuffer = nextNode.LineBuffer
 		c.currentCharInset = nextNode.PieceTable.Length()
 	case OpLeftStartCursor:
-		fmt.Println("Cursor -> LeftStartCursor 실행")
 		c.currentCharInset = 0
 	case OpRightEndCursor:
-		fmt.Println("Cursor -> RightEndCursor 실행")
 		c.currentCharInset = currentNode.PieceTable.Length()
 	case OpHoldCursor:
-		fmt.Println("Cursor -> HoldCursor 실행")
+		// 아무것도 안 함
 	default:
-		fmt.Println("Cursor -> 알 수 없는 opCode")
+		sp.logger.Warn("알 수 없는 op 코드", "op", co.Info())
 	}
 }
 
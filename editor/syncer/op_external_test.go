@@ -0,0 +1,59 @@
+package syncer_test
+
+import (
+	"go_editor/editor/commander"
+	"go_editor/editor/storage"
+	"go_editor/editor/syncer"
+	"strings"
+	"testing"
+)
+
+// upperNextOp: 다른 패키지에서 만든 op (커서 다음 라인을 대문자로 바꾸고 커서를 그 끝으로)
+type upperNextOp struct {
+	kind   syncer.OpKind
+	target *syncer.SyncNode
+}
+
+func (u upperNextOp) Info() syncer.OpInfo {
+	return syncer.OpInfo{Kind: u.kind, Target: u.target}
+}
+
+func (u upperNextOp) Execute(sp *syncer.SyncProtocol) {
+	text := strings.ToUpper(u.target.Text())
+	sp.SetLineText(u.target, text)
+	sp.SetCursor(u.target, len([]rune(text)))
+}
+
+func TestExternalOp(t *testing.T) {
+	const cmdUpperNext = commander.CommandCode(201)
+	kind := syncer.RegisterOpKind("Case", "upperNext")
+	syncer.RegisterOpBuilder(cmdUpperNext, syncer.OpBuilder{Edit: true, Build: func(sp *syncer.SyncProtocol, cmd commander.Command) (*syncer.OpSequences, bool) {
+		ops := syncer.NewOpSequences()
+		if next := sp.CursorNode().Next(); next != nil {
+			ops.Append(upperNextOp{kind: kind, target: next})
+		}
+		return ops, true
+	}})
+	defer syncer.UnregisterOpBuilder(cmdUpperNext)
+
+	sp := syncer.LoadSyncProtocol(storage.NewMemoryStorage([]byte("abc\ndéf\nghi")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	var events []syncer.ChangeEvent
+	sp.Subscribe(func(ev syncer.ChangeEvent) { events = append(events, ev) })
+
+	sp.ProcessCommand(commander.Command{Code: cmdUpperNext})
+	doc := sp.Document()
+	if line, _ := doc.Line(1); line != "DÉF" {
+		t.Fatalf("op 결과 = %q", line)
+	}
+	if sp.LineIndex(sp.CursorNode()) != 1 || sp.CursorCol() != 3 || !sp.IsDirty() {
+		t.Fatalf("커서 (%d, %d), dirty %v", sp.LineIndex(sp.CursorNode()), sp.CursorCol(), sp.IsDirty())
+	}
+	if len(events) != 1 || events[0].Kind != syncer.NodeModified || events[0].Line != 1 {
+		t.Fatalf("이벤트 = %+v", events)
+	}
+
+	sp.Undo()
+	if line, _ := doc.Line(1); line != "déf" || sp.LineIndex(sp.CursorNode()) != 0 {
+		t.Fatalf("되돌리기 결과 = %q, 커서 라인 %d", line, sp.LineIndex(sp.CursorNode()))
+	}
+}
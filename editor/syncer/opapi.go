@@ -0,0 +1,59 @@
+package syncer
+
+// 외부 패키지의 Op가 Execute 안에서 쓰는 접근자
+// 노드를 고치는 op는 Info().Target에 그 노드를 넣어야 롤백/되돌리기에 포함됨 (커서 노드는 항상 포함)
+// Execute 안에서 Document의 Insert/Delete를 부르면 안 됨 (실행이 끝나기 전에 이벤트가 나감)
+
+// CursorNode: 커서가 있는 라인의 노드
+func (sp *SyncProtocol) CursorNode() *SyncNode {
+	return sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+}
+
+// CursorCol: 커서의 칸 (글자 단위, 0부터)
+func (sp *SyncProtocol) CursorCol() int {
+	return sp.cursor.currentCharInset
+}
+
+// SetCursor: 커서를 노드의 col 칸으로 (라인 길이 안으로 맞춤). 리스트에 없는 노드면 무시
+func (sp *SyncProtocol) SetCursor(n *SyncNode, col int) {
+	if n == nil || n.LineBuffer == nil {
+		return
+	}
+	sp.cursor.currentLineBuffer = n.LineBuffer
+	sp.cursor.currentCharInset = min(max(col, 0), n.PieceTable.Length())
+}
+
+// LineIndex: 노드의 라인 번호 (0부터, 리스트에 없으면 -1)
+func (sp *SyncProtocol) LineIndex(n *SyncNode) int {
+	return sp.doc.data.findOrder(n)
+}
+
+// SetLineText: 노드의 텍스트를 바꾸고 다시 그림. 구독자에게는 NodeModified로 알림
+// 커서가 이 라인에 있으면 칸을 새 길이 안으로 맞춤
+func (sp *SyncProtocol) SetLineText(n *SyncNode, text string) {
+	ev := ChangeEvent{Kind: NodeModified}
+	if sp.Observed() {
+		ev.Line, ev.Before, ev.After = sp.LineIndex(n), []string{n.PieceTable.String()}, []string{text}
+	}
+	n.PieceTable = NewPieceTable(text)
+	sp.EmitChange(ev)
+	if n.LineBuffer == sp.cursor.currentLineBuffer {
+		sp.cursor.currentCharInset = min(sp.cursor.currentCharInset, n.PieceTable.Length())
+	}
+	sp.syncNode(n)
+}
+
+// Text: 라인의 텍스트
+func (sn *SyncNode) Text() string {
+	return sn.PieceTable.String()
+}
+
+// Next: 다음 라인의 노드 (마지막이면 nil)
+func (sn *SyncNode) Next() *SyncNode {
+	return sn.next
+}
+
+// Prev: 이전 라인의 노드 (처음이면 nil)
+func (sn *SyncNode) Prev() *SyncNode {
+	return sn.prev
+}
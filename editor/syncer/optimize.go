@@ -0,0 +1,140 @@
+package syncer
+
+import "strings"
+
+// optimize: 빌드와 실행 사이의 최적화 패스
+//  1. 아무것도 안 하는 op(Hold 계열, Modify 표시) 제거
+//  2. 같은 노드의 중복 Modified 싱크는 마지막 것만 남김
+//  3. 연속된 InsertRune+RightCursor 쌍은 TypeText 하나로 묶음 (빠른 입력)
+//
+// 롤백 대상은 커서 노드를 항상 포함하므로 Modify 표시를 빼도 tx 수집에는 영향 없음
+func optimize(ops *OpSequences) *OpSequences {
+	out := dropNoOps(ops.ops)
+	out = dropRedundantSyncs(out)
+	out = coalesceTyping(out)
+	return &OpSequences{ops: out}
+}
+
+// isNoOp: 실행해도 상태가 바뀌지 않는 기본 op
+func isNoOp(op Op) bool {
+	info := op.Info()
+	switch info.Kind {
+	case OpKindNodeGroup:
+		return info.Code == int(OpHoldAllGroup) || info.Code == int(OpModifyNodeOnGroup)
+	case OpKindNodeText:
+		return info.Code == int(OpHoldRune)
+	case OpKindSync:
+		return info.Code == int(OpNodeHoldSync)
+	case OpKindCursor:
+		return info.Code == int(OpHoldCursor)
+	}
+	return false
+}
+
+func dropNoOps(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for _, op := range ops {
+		if !isNoOp(op) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// dropRedundantSyncs: 뒤에 같은 노드의 Modified 싱크가 있고, 그 사이에 노드 구조를
+// 바꾸는 op(NodeGroup)나 모르는 종류의 op가 없으면 앞의 싱크는 필요 없음
+func dropRedundantSyncs(ops []Op) []Op {
+	keep := make([]bool, len(ops))
+	// 뒤에서부터: 이미 뒤에서 싱크될 노드 집합
+	pending := map[*SyncNode]bool{}
+	for i := len(ops) - 1; i >= 0; i-- {
+		keep[i] = true
+		info := ops[i].Info()
+		switch info.Kind {
+		case OpKindSync:
+			if info.Code != int(OpNodeModifiedSync) {
+				pending = map[*SyncNode]bool{}
+				continue
+			}
+			if pending[info.Target] {
+				keep[i] = false
+			}
+			pending[info.Target] = true
+		case OpKindNodeText, OpKindCursor:
+			// 노드 구조는 그대로
+		default:
+			pending = map[*SyncNode]bool{}
+		}
+	}
+	out := make([]Op, 0, len(ops))
+	for i, op := range ops {
+		if keep[i] {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// coalesceTyping: InsertRune, (Modified 싱크), RightCursor가 반복되면 TypeText 하나로
+// 싱크는 커서 이동과 순서가 상관없으므로 묶은 TypeText 뒤로 옮김
+func coalesceTyping(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for i := 0; i < len(ops); {
+		var sb strings.Builder
+		var syncs []Op
+		pairs := 0
+		j := i
+		for {
+			next, sync, ok := typingPairAt(ops, j)
+			if !ok {
+				break
+			}
+			sb.WriteRune(ops[j].Info().Char)
+			if sync != nil && !containsSync(syncs, sync) {
+				syncs = append(syncs, sync)
+			}
+			pairs++
+			j = next
+		}
+		if pairs < 2 {
+			// 쌍이 하나뿐이면 그대로 둠
+			out = append(out, ops[i])
+			i++
+			continue
+		}
+		out = append(out, NewOpTypeText(sb.String()))
+		out = append(out, syncs...)
+		i = j
+	}
+	return out
+}
+
+// typingPairAt: ops[i]부터 InsertRune, (Modified 싱크), RightCursor이면 다음 위치와 싱크 리턴
+func typingPairAt(ops []Op, i int) (next int, sync Op, ok bool) {
+	if i >= len(ops) || !hasCode(ops[i], OpKindNodeText, int(OpInsertRune)) {
+		return 0, nil, false
+	}
+	k := i + 1
+	if k < len(ops) && hasCode(ops[k], OpKindSync, int(OpNodeModifiedSync)) {
+		sync = ops[k]
+		k++
+	}
+	if k >= len(ops) || !hasCode(ops[k], OpKindCursor, int(OpRightCursor)) {
+		return 0, nil, false
+	}
+	return k + 1, sync, true
+}
+
+func hasCode(op Op, kind OpKind, code int) bool {
+	info := op.Info()
+	return info.Kind == kind && info.Code == code
+}
+
+func containsSync(syncs []Op, sync Op) bool {
+	for _, s := range syncs {
+		if s.Info().Target == sync.Info().Target {
+			return true
+		}
+	}
+	return false
+}
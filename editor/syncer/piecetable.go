@@ -2,16 +2,28 @@ package syncer
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
 // -------------------------------------
 // (1) 기본 구조체 정의
 // -------------------------------------
 
+// PieceTable: 원본/추가 버퍼와 조각 트리
+// 버퍼는 UTF-8 바이트로 두고 (글자당 1~4바이트), 조각은 버퍼 안의 글자 위치로 가리킴
+// 조각들은 글자 수를 서브트리마다 캐시한 균형 트리(트립)에 두어서
+// 길이는 O(1), 인덱스로 조각 찾기/삽입/삭제/분할은 O(log n)
+//
+// 버퍼 소유 규칙: 두 버퍼 모두 여러 테이블이 포인터로 공유할 수 있음 (분할, clone)
+//   - originalBuffer는 읽기 전용
+//   - addBuffer는 공유되는 덧붙이기 전용 버퍼. 어느 테이블이든 끝에만 붙이고 이미 쓴 부분은 바꾸지 않음
+//
+// 그래서 한 테이블의 조각이 가리키는 내용은 다른 테이블이 무엇을 하든 변하지 않는다.
+// 지운 텍스트가 쌓여 추가 버퍼가 커지면 compact로 살아 있는 부분만 새 버퍼에 옮김
 type PieceTable struct {
-	originalBuffer []rune
-	addBuffer      []rune
-	pieces         []Piece
+	originalBuffer *textBuffer // 불러온 텍스트 (파일 매핑일 수 있음)
+	addBuffer      *textBuffer // 입력한 텍스트
+	root           *pieceNode
 	parent         *PieceTable // 히스토리(Undo/Redo) 지원을 위한 원본 참조
 }
 
@@ -30,59 +42,40 @@ const (
 
 // 새로운 PieceTable 생성 (초기 텍스트)
 func NewPieceTable(initialText string) *PieceTable {
+	return NewPieceTableBytes([]byte(initialText))
+}
+
+// NewPieceTableBytes: UTF-8 바이트를 복사하지 않고 원본 버퍼로 씀
+// data는 파일의 읽기 전용 매핑이어도 되고, 이후 호출자가 고치면 안 됨
+func NewPieceTableBytes(data []byte) *PieceTable {
 	pt := &PieceTable{
-		originalBuffer: []rune(initialText),
-		addBuffer:      make([]rune, 0),
-		pieces:         make([]Piece, 0),
+		originalBuffer: newTextBuffer(data),
+		addBuffer:      newTextBuffer(nil),
 		parent:         nil, // 초기 생성은 부모 없음
 	}
-	if len(pt.originalBuffer) > 0 {
-		pt.pieces = append(pt.pieces, Piece{
+	if pt.originalBuffer.runes > 0 {
+		pt.root = pt.newNode(Piece{
 			Kind:   BufferOriginal,
 			Start:  0,
-			Length: len(pt.originalBuffer),
+			Length: pt.originalBuffer.runes,
 		})
 	}
 	return pt
 }
 
+// Length: 글자 수 (루트에 캐시된 값)
 func (pt *PieceTable) Length() int {
-	total := 0
-	for _, p := range pt.pieces {
-		total += p.Length
-	}
-	return total
+	return pt.root.size()
 }
 
 func (pt *PieceTable) String() string {
 	var sb strings.Builder
-	for _, piece := range pt.pieces {
-		switch piece.Kind {
-		case BufferOriginal:
-			sb.WriteString(string(pt.originalBuffer[piece.Start : piece.Start+piece.Length]))
-		case BufferAdd:
-			sb.WriteString(string(pt.addBuffer[piece.Start : piece.Start+piece.Length]))
-		}
-	}
+	pt.root.forEach(func(piece Piece) {
+		sb.Write(pt.buffer(piece.Kind).bytes(piece.Start, piece.Length))
+	})
 	return sb.String()
 }
 
-// findPieceAtRuneIndex: 문서상의 0-based 인덱스에 해당하는 piece와 내부 offset 반환
-func (pt *PieceTable) findPieceAtRuneIndex(index int) (pieceIndex int, internalOffset int) {
-	if index < 0 {
-		println("findPieceAtRuneIndex: index <= 0")
-		return -1, -1
-	}
-	var sum int
-	for i, piece := range pt.pieces {
-		if index < sum+piece.Length {
-			return i, index - sum
-		}
-		sum += piece.Length
-	}
-	return len(pt.pieces) - 1, pt.pieces[len(pt.pieces)-1].Length
-}
-
 // -------------------------------------
 // (2) Insert / Delete
 //     이제 모두 zero-based로, 정확히 해당 인덱스에서 연산하도록 함
@@ -103,54 +96,19 @@ func (pt *PieceTable) Insert(index int, newText string) {
 		realOffset = pt.Length()
 	}
 
-	newRunes := []rune(newText)
-	startPosInAdd := len(pt.addBuffer)
-	pt.addBuffer = append(pt.addBuffer, newRunes...)
-
-	newPiece := Piece{
-		Kind:   BufferAdd,
-		Start:  startPosInAdd,
-		Length: len(newRunes),
-	}
-
-	// 만약 realOffset가 문서 끝이면 그냥 뒤에 붙임
-	if realOffset == pt.Length() {
-		pt.pieces = append(pt.pieces, newPiece)
+	if newText == "" {
 		return
 	}
-
-	pieceIndex, internalOffset := pt.findPieceAtRuneIndex(realOffset)
-	if pieceIndex < 0 {
-		// 문서가 비어있는 경우
-		return
-	}
-	oldPiece := pt.pieces[pieceIndex]
-
-	frontLength := internalOffset                  // 앞쪽은 index 이전까지
-	backLength := oldPiece.Length - internalOffset // 뒷쪽은 index부터 끝까지
-
-	// 앞부분: 만약 frontLength > 0이면 남김
-	if frontLength > 0 {
-		pt.pieces[pieceIndex].Length = frontLength
-	} else {
-		// front가 없다면 제거
-		pt.pieces = append(pt.pieces[:pieceIndex], pt.pieces[pieceIndex+1:]...)
-		pieceIndex--
+	newPiece := Piece{
+		Kind:   BufferAdd,
+		Start:  pt.addBuffer.appendString(newText),
+		Length: utf8.RuneCountInString(newText),
 	}
+	defer pt.compactIfSparse()
 
-	// 새 piece 삽입: 정확히 index 위치에 삽입됨
-	pt.pieces = insertPiece(pt.pieces, newPiece, pieceIndex+1)
-	pieceIndex++
-
-	// 뒷부분: 남은 뒤쪽이 있다면 새 piece로 삽입
-	if backLength > 0 {
-		backPiece := Piece{
-			Kind:   oldPiece.Kind,
-			Start:  oldPiece.Start + internalOffset,
-			Length: backLength,
-		}
-		pt.pieces = insertPiece(pt.pieces, backPiece, pieceIndex+1)
-	}
+	// index에서 트리를 나누고 새 조각을 가운데에 끼움 (걸친 조각은 split에서 둘로 나뉨)
+	front, back := pt.split(pt.root, realOffset)
+	pt.root = mergePieces(mergePieces(front, pt.newNode(newPiece)), back)
 }
 
 // Delete(start, length):
@@ -186,76 +144,11 @@ func (pt *PieceTable) Delete(cursor, length int) {
 		return
 	}
 
-	// 1) 시작 지점의 Piece index & offset 구하기
-	pieceIndex, offsetInPiece := pt.findPieceAtRuneIndex(start)
-	if pieceIndex < 0 {
-		// 문서가 비어있는 경우
-		return
-	}
-	var processed int
-	for processed < toDelete && pieceIndex < len(pt.pieces) {
-		piece := pt.pieces[pieceIndex]
-		pieceLen := piece.Length
-
-		// 현재 piece에서 지울 수 있는 범위
-		canDelete := pieceLen - offsetInPiece
-		remain := toDelete - processed
-		if remain < canDelete {
-			canDelete = remain
-		}
-
-		frontLen := offsetInPiece
-		backLen := pieceLen - offsetInPiece - canDelete
-
-		switch {
-		case frontLen == 0 && backLen == 0:
-			// piece 전체가 삭제됨
-			pt.pieces = append(pt.pieces[:pieceIndex], pt.pieces[pieceIndex+1:]...)
-			// pieceIndex 증가 안 함 (다음 piece가 현재 인덱스로 옴)
-
-		case frontLen == 0:
-			// 앞부분 없음, 뒷부분만 남김
-			piece.Start += canDelete
-			piece.Length = backLen
-			pt.pieces[pieceIndex] = piece
-			pieceIndex++
-
-		case backLen == 0:
-			// 앞부분만 남고, 뒷부분은 없음
-			piece.Length = frontLen
-			pt.pieces[pieceIndex] = piece
-			pieceIndex++
-
-		default:
-			// 앞/뒤가 모두 남음 -> piece를 split
-			piece.Length = frontLen
-			pt.pieces[pieceIndex] = piece
-			newPiece := Piece{
-				Kind:   piece.Kind,
-				Start:  piece.Start + frontLen + canDelete,
-				Length: backLen,
-			}
-			pt.pieces = insertPiece(pt.pieces, newPiece, pieceIndex+1)
-			pieceIndex += 2
-		}
-
-		processed += canDelete
-		offsetInPiece = 0 // 다음 piece부터는 앞부분 소진 없음
-	}
-}
-
-// insertPiece: 슬라이스 중간에 새 Piece를 삽입하기 위한 유틸 함수
-func insertPiece(pieces []Piece, newPiece Piece, idx int) []Piece {
-	if idx < 0 {
-		idx = 0
-	}
-	if idx > len(pieces) {
-		idx = len(pieces)
-	}
-	pieces = append(pieces, Piece{})
-	copy(pieces[idx+1:], pieces[idx:])
-	pieces[idx] = newPiece
-	return pieces
+	// [start, end)를 트리에서 떼어내고 앞뒤를 다시 이음
+	front, rest := pt.split(pt.root, start)
+	removed, back := pt.split(rest, toDelete)
+	pt.root = mergePieces(front, back)
+	pt.addBuffer.live -= removed.addRunes()
 }
 
 // -------------------------------------
@@ -281,61 +174,77 @@ func (pt *PieceTable) DeleteRune(index int) {
 
 func (pt *PieceTable) SlicePieceTable(index int) (*PieceTable, *PieceTable) {
 	if index <= 0 || index > pt.Length() {
-		println("SlicePieceTable: index는 0보다 커야 슬라이싱 가능합니다")
 		return nil, nil
 	}
 
+	// 트리 노드는 바꾸지 않고 버퍼는 공유 규칙을 따르므로 나눠도 pt는 그대로 남음
+	// frontPT는 [0,index)이고, backPT는 [index, end)이다.
+	front, back := pt.split(pt.root, index)
 	frontPT := &PieceTable{
 		originalBuffer: pt.originalBuffer,
 		addBuffer:      pt.addBuffer,
-		pieces:         []Piece{},
+		root:           front,
 		parent:         pt,
 	}
 	backPT := &PieceTable{
 		originalBuffer: pt.originalBuffer,
 		addBuffer:      pt.addBuffer,
-		pieces:         []Piece{},
+		root:           back,
 		parent:         pt,
 	}
+	return frontPT, backPT
+}
 
-	// 여기서는 index를 포함하지 않으므로,
-	// frontPT는 [0,index)이고, backPT는 [index, end)이다.
-	pieceIndex, offsetInPiece := pt.findPieceAtRuneIndex(index)
-
-	for i, piece := range pt.pieces {
-		if i < pieceIndex {
-			frontPT.pieces = append(frontPT.pieces, piece)
-		} else if i > pieceIndex {
-			backPT.pieces = append(backPT.pieces, piece)
-		} else {
-			// 현재 분할 대상 Piece:
-			// front는 앞부분 [piece.Start, piece.Start+offsetInPiece)
-			// back은 뒷부분 [piece.Start+offsetInPiece, piece.Start+piece.Length)
-			frontPiece := Piece{
-				Kind:   piece.Kind,
-				Start:  piece.Start,
-				Length: offsetInPiece,
-			}
-			backPiece := Piece{
-				Kind:   piece.Kind,
-				Start:  piece.Start + offsetInPiece,
-				Length: piece.Length - offsetInPiece,
-			}
-
-			if frontPiece.Length > 0 {
-				frontPT.pieces = append(frontPT.pieces, frontPiece)
-			}
-			if backPiece.Length > 0 {
-				backPT.pieces = append(backPT.pieces, backPiece)
-			}
+// -------------------------------------
+// (5) 줄 찾기 (문서 전체를 담은 테이블용)
+//     조각 트리에 캐시된 줄바꿈 수로 O(log n)
+// -------------------------------------
+
+// LineCount: 줄 수 (줄바꿈 수 + 1)
+func (pt *PieceTable) LineCount() int {
+	return pt.root.lineBreaks() + 1
+}
+
+// LineStart: i번째(0부터) 줄이 시작하는 글자 위치
+func (pt *PieceTable) LineStart(i int) int {
+	if i <= 0 {
+		return 0
+	}
+	return pt.lineStart(pt.root, i)
+}
+
+// LineAt: 글자 위치 offset이 있는 줄 번호 (offset 앞의 줄바꿈 수)
+func (pt *PieceTable) LineAt(offset int) int {
+	line := 0
+	for n := pt.root; n != nil; {
+		leftLen := n.left.size()
+		switch {
+		case offset < leftLen:
+			n = n.left
+		case offset < leftLen+n.piece.Length:
+			return line + n.left.lineBreaks() + pt.buffer(n.piece.Kind).newlinesIn(n.piece.Start, offset-leftLen)
+		default:
+			line += n.left.lineBreaks() + n.newlines
+			offset -= leftLen + n.piece.Length
+			n = n.right
 		}
 	}
+	return line
+}
 
-	return frontPT, backPT
+// Substring: [start, end) 글자의 텍스트 (트리를 고치지 않음)
+func (pt *PieceTable) Substring(start, end int) string {
+	_, rest := pt.split(pt.root, start)
+	mid, _ := pt.split(rest, end-start)
+	var sb strings.Builder
+	mid.forEach(func(piece Piece) {
+		sb.Write(pt.buffer(piece.Kind).bytes(piece.Start, piece.Length))
+	})
+	return sb.String()
 }
 
 // -------------------------------------
-// (5) 테스트 코드
+// (6) 테스트 코드
 // -------------------------------------
 
 // func main() {
@@ -373,3 +282,68 @@ func (pt *PieceTable) SlicePieceTable(index int) (*PieceTable, *PieceTable) {
 // 	front, back = pt.SlicePieceTable(6)
 // 	fmt.Println("[SlicePieceTable(6)] Front:", front.String(), "Back:", back.String())
 // }
+
+// clone: 롤백용 사본. 버퍼는 덧붙이기만 하고 트리 노드는 바꾸지 않으므로 둘 다 공유
+func (pt *PieceTable) clone() *PieceTable {
+	c := *pt
+	return &c
+}
+
+// Concat: pt 뒤에 other를 이은 새 테이블 (둘 다 그대로 남음)
+// 같은 버퍼를 쓰면 (같은 라인을 나눴던 경우) 조각 트리만 잇고, 아니면 other의 텍스트를 추가 버퍼에 복사
+func (pt *PieceTable) Concat(other *PieceTable) *PieceTable {
+	c := pt.clone()
+	c.parent = pt
+	if other.originalBuffer == pt.originalBuffer && other.addBuffer == pt.addBuffer {
+		c.root = mergePieces(pt.root, other.root)
+		return c
+	}
+	if text := other.String(); text != "" {
+		c.root = mergePieces(pt.root, c.newNode(Piece{
+			Kind:   BufferAdd,
+			Start:  c.addBuffer.appendString(text),
+			Length: utf8.RuneCountInString(text),
+		}))
+		c.compactIfSparse()
+	}
+	return c
+}
+
+// 추가 버퍼가 이보다 작으면 정리하지 않음 (글자 수)
+const compactMinRunes = 4096
+
+// compactIfSparse: 추가 버퍼가 살아 있는 글자 수(live)의 4배를 넘으면 정리
+// 한 테이블의 길이가 아니라 버퍼 기준으로 보므로, 긴 라인을 나눈 짧은 반쪽들은 버퍼를 계속 나눠 씀
+// 정리는 지운 글자가 쌓였을 때만 일어나므로 비용은 지운 글자 수에 나눠서 O(1)
+func (pt *PieceTable) compactIfSparse() {
+	if n := pt.addBuffer.runes; n > compactMinRunes && n > 4*pt.addBuffer.live {
+		pt.compact()
+	}
+}
+
+// addRunes: 서브트리 조각 중 추가 버퍼를 가리키는 글자 수
+func (n *pieceNode) addRunes() int {
+	total := 0
+	n.forEach(func(p Piece) {
+		if p.Kind == BufferAdd {
+			total += p.Length
+		}
+	})
+	return total
+}
+
+// compact: 이 테이블이 가리키는 추가 버퍼 텍스트만 새 버퍼로 옮기고 조각을 다시 만듦
+// 공유하던 옛 버퍼는 건드리지 않으므로 다른 테이블(분할한 반쪽, 롤백 사본)은 그대로 유효
+func (pt *PieceTable) compact() {
+	old, pieces := pt.addBuffer, pt.root
+	pt.addBuffer = newTextBuffer(nil)
+	pt.root = nil
+	pieces.forEach(func(p Piece) {
+		if p.Kind == BufferAdd {
+			text := old.bytes(p.Start, p.Length)
+			p.Start = pt.addBuffer.appendString(string(text))
+			old.live -= p.Length
+		}
+		pt.root = mergePieces(pt.root, pt.newNode(p))
+	})
+}
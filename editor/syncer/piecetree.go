@@ -0,0 +1,124 @@
+package syncer
+
+import "math/rand/v2"
+
+// pieceNode: 조각 트리의 노드 (트립: 순서는 문서 순서, priority는 힙)
+// 한 번 만든 노드는 고치지 않고 바뀌는 경로만 새로 만든다 (경로 복사)
+// 그래서 clone/SlicePieceTable이 트리를 공유해도 서로 영향이 없음
+type pieceNode struct {
+	piece       Piece
+	newlines    int // 이 조각 안의 줄바꿈 수
+	left, right *pieceNode
+	priority    uint32
+	length      int // 서브트리 전체의 글자 수
+	lines       int // 서브트리 전체의 줄바꿈 수
+}
+
+// newNode: 조각 하나짜리 노드. 줄바꿈 수는 조각이 가리키는 버퍼에서 셈
+func (pt *PieceTable) newNode(p Piece) *pieceNode {
+	nl := pt.buffer(p.Kind).newlinesIn(p.Start, p.Length)
+	return &pieceNode{piece: p, newlines: nl, priority: rand.Uint32(), length: p.Length, lines: nl}
+}
+
+// buffer: 조각 종류에 맞는 버퍼
+func (pt *PieceTable) buffer(kind BufferKind) *textBuffer {
+	if kind == BufferOriginal {
+		return pt.originalBuffer
+	}
+	return pt.addBuffer
+}
+
+// size: 서브트리 글자 수 (nil이면 0)
+func (n *pieceNode) size() int {
+	if n == nil {
+		return 0
+	}
+	return n.length
+}
+
+// lineBreaks: 서브트리 줄바꿈 수 (nil이면 0)
+func (n *pieceNode) lineBreaks() int {
+	if n == nil {
+		return 0
+	}
+	return n.lines
+}
+
+// with: 자식만 바꾼 사본
+func (n *pieceNode) with(left, right *pieceNode) *pieceNode {
+	c := *n
+	c.left, c.right = left, right
+	c.length = left.size() + c.piece.Length + right.size()
+	c.lines = left.lineBreaks() + c.newlines + right.lineBreaks()
+	return &c
+}
+
+// forEach: 조각을 문서 순서대로
+func (n *pieceNode) forEach(fn func(Piece)) {
+	if n == nil {
+		return
+	}
+	n.left.forEach(fn)
+	fn(n.piece)
+	n.right.forEach(fn)
+}
+
+// split: [0,index)와 [index,end)의 두 트리로 나눔. index에 걸친 조각은 둘로 자름
+func (pt *PieceTable) split(n *pieceNode, index int) (front, back *pieceNode) {
+	if n == nil {
+		return nil, nil
+	}
+	leftLen := n.left.size()
+	switch {
+	case index <= leftLen:
+		front, back = pt.split(n.left, index)
+		return front, n.with(back, n.right)
+	case index >= leftLen+n.piece.Length:
+		front, back = pt.split(n.right, index-leftLen-n.piece.Length)
+		return n.with(n.left, front), back
+	default:
+		// 조각 가운데: 앞/뒤 조각이 같은 priority를 이어받으면 힙 조건이 유지됨
+		offset := index - leftLen
+		head, tail := *n, *n
+		head.piece.Length = offset
+		head.newlines = pt.buffer(n.piece.Kind).newlinesIn(head.piece.Start, offset)
+		tail.piece.Start += offset
+		tail.piece.Length -= offset
+		tail.newlines = n.newlines - head.newlines
+		return head.with(n.left, nil), tail.with(nil, n.right)
+	}
+}
+
+// mergePieces: front 뒤에 back을 이은 트리 (front의 모든 조각이 back보다 앞)
+func mergePieces(front, back *pieceNode) *pieceNode {
+	switch {
+	case front == nil:
+		return back
+	case back == nil:
+		return front
+	case front.priority >= back.priority:
+		return front.with(front.left, mergePieces(front.right, back))
+	default:
+		return back.with(mergePieces(front, back.left), back.right)
+	}
+}
+
+// lineStart: k번째(1부터) 줄바꿈 바로 뒤의 글자 위치. 줄바꿈이 든 서브트리로만 내려감
+func (pt *PieceTable) lineStart(n *pieceNode, k int) int {
+	offset := 0
+	for n != nil {
+		leftLines := n.left.lineBreaks()
+		switch {
+		case k <= leftLines:
+			n = n.left
+		case k <= leftLines+n.newlines:
+			nl := pt.buffer(n.piece.Kind).nthNewline(n.piece.Start, k-leftLines-1)
+			return offset + n.left.size() + nl - n.piece.Start + 1
+		default:
+			k -= leftLines + n.newlines
+			offset += n.left.size() + n.piece.Length
+			n = n.right
+		}
+	}
+	return offset
+}
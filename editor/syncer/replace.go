@@ -0,0 +1,317 @@
+package syncer
+
+import (
+	"fmt"
+	"go_editor/editor/commander"
+	"go_editor/editor/storage"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// replacePlan: 치환 대상과 매치들
+// 라인 경계를 넘는 매치를 위해 문서 전체를 \n으로 이어서 찾음
+type replacePlan struct {
+	re       *regexp.Regexp
+	template string
+
+	text       string
+	lineStarts []int // text 안의 각 라인 시작 바이트 위치
+	from, to   int   // 치환 범위 [from, to) (text 기준 바이트)
+	matches    [][]int
+}
+
+// planReplace: 범위/플래그에 맞춰 매치를 모두 찾음
+func (sp *SyncProtocol) planReplace(in commander.SubstituteInput) (*replacePlan, error) {
+	pattern := "(?m)" + in.Pattern // ^, $는 라인 단위
+	if in.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &replacePlan{re: re, template: unescapeReplacement(in.Replacement)}
+	var b strings.Builder
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		if len(plan.lineStarts) > 0 {
+			b.WriteByte('\n')
+		}
+		plan.lineStarts = append(plan.lineStarts, b.Len())
+		b.WriteString(sn.PieceTable.String())
+	})
+	plan.text = b.String()
+
+	switch in.Range {
+	case commander.RangeDocument:
+		plan.from, plan.to = 0, len(plan.text)
+	case commander.RangeSelection:
+		start, end, ok := sp.selectionRange()
+		if !ok {
+			return nil, fmt.Errorf("no selection (Ctrl+Space)")
+		}
+		plan.from, plan.to = plan.offsetOf(start), plan.offsetOf(end)
+	default:
+		line := sp.cursorLineIndex()
+		plan.from = plan.lineStarts[line]
+		plan.to = plan.lineEnd(line)
+	}
+
+	// 범위만 잘라서 찾으면 ^, $, \b가 범위 끝에서도 맞으므로 전체에서 찾고 범위 안의 매치만 씀
+	lastLine := -1
+	for _, m := range re.FindAllStringSubmatchIndex(plan.text, -1) {
+		if m[0] < plan.from || m[1] > plan.to {
+			continue
+		}
+		// g가 없으면 라인마다 첫 매치만
+		if !in.Global {
+			line := plan.posOf(m[0]).line
+			if line == lastLine {
+				continue
+			}
+			lastLine = line
+		}
+		plan.matches = append(plan.matches, m)
+	}
+	return plan, nil
+}
+
+// unescapeReplacement: 치환 문자열의 \n, \t, \\ 처리 ($1 등은 regexp가 처리)
+func unescapeReplacement(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(s)
+}
+
+func (p *replacePlan) lineEnd(line int) int {
+	if line+1 < len(p.lineStarts) {
+		return p.lineStarts[line+1] - 1
+	}
+	return len(p.text)
+}
+
+// offsetOf: 문서 위치 -> text 바이트 위치
+func (p *replacePlan) offsetOf(pos docPos) int {
+	start := p.lineStarts[pos.line]
+	return start + runeOffset(p.text[start:p.lineEnd(pos.line)], pos.inset)
+}
+
+// posOf: text 바이트 위치 -> 문서 위치
+func (p *replacePlan) posOf(offset int) docPos {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset }) - 1
+	return docPos{line, insetOf(p.text[p.lineStarts[line]:], offset-p.lineStarts[line])}
+}
+
+// expand: i번째 매치를 바꿀 문자열
+func (p *replacePlan) expand(i int) string {
+	return string(p.re.ExpandString(nil, p.template, p.text, p.matches[i]))
+}
+
+// apply: accept가 true인 매치만 바꿈. 매치가 있는 라인 범위만 고치고 전체가 되돌리기 한 번
+func (sp *SyncProtocol) applyReplace(p *replacePlan, accept func(i int) bool) int {
+	var b strings.Builder
+	first, last, pos, count := 0, 0, 0, 0
+	for i, m := range p.matches {
+		if !accept(i) {
+			continue
+		}
+		if count == 0 {
+			first = p.posOf(m[0]).line
+			pos = p.lineStarts[first]
+		}
+		b.WriteString(p.text[pos:m[0]])
+		b.WriteString(p.expand(i))
+		pos = m[1]
+		last = p.posOf(m[1]).line
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	b.WriteString(p.text[pos:p.lineEnd(last)])
+
+	sp.withCursorHidden(func() {
+		sp.mark = nil
+		sp.pushUndo(sp.replaceLines(first, last, strings.Split(b.String(), "\n")))
+		sp.breakUndoGroup()
+		sp.refreshSelection()
+	})
+	sp.refreshCurrentLine()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+	return count
+}
+
+// replaceLines: [first, last] 라인을 lines로 바꾸고 되돌리기용 트랜잭션을 돌려줌
+// 기존 노드를 앞에서부터 다시 쓰고, 모자라면 새 노드를 끼우고 남으면 뺌. 커서는 같은 라인/칸 근처로
+func (sp *SyncProtocol) replaceLines(first, last int, lines []string) *opTx {
+	data := sp.doc.data
+	observed := sp.doc.observed()
+	cursor := sp.cursorPos()
+	tx := newTx(sp)
+	node, _ := data.findNode(uint(first))
+	var prev *SyncNode
+	for i, line := range lines {
+		if i <= last-first {
+			tx.capture(node)
+			ev := ChangeEvent{Kind: NodeModified, Line: first + i}
+			if observed {
+				ev.Before, ev.After = []string{node.PieceTable.String()}, []string{line}
+			}
+			node.PieceTable = NewPieceTable(line)
+			sp.EmitChange(ev)
+			prev, node = node, node.next
+			continue
+		}
+		// 새 노드는 커서가 있을 수 없으므로 라인버퍼를 바로 붙임 (syncNode의 커서 확인을 건너뜀)
+		prev = data.insertAfter(prev, line)
+		prev.LineBuffer = sp.NewLineBuffer()
+		tx.nodes[prev] = nodeState{}
+		sp.EmitChange(ChangeEvent{Kind: NodeInserted, Line: first + i, After: []string{line}})
+	}
+	for i := len(lines); i <= last-first; i++ {
+		next := node.next
+		tx.capture(node)
+		ev := ChangeEvent{Kind: NodeDeleted, Line: first + len(lines)}
+		if observed {
+			ev.Before = []string{node.PieceTable.String()}
+		}
+		data.deleteByPtr(node)
+		sp.EmitChange(ev)
+		node = next
+	}
+	sp.doc.publishChanges()
+
+	target, found := data.findNode(uint(cursor.line))
+	if !found {
+		target = data.head
+		for !target.IsDownEnd() {
+			target = target.next
+		}
+	}
+	sp.cursor.currentLineBuffer = target.LineBuffer
+	sp.cursor.currentCharInset = min(cursor.inset, target.PieceTable.Length())
+	for n := range tx.nodes {
+		sp.syncNode(n)
+	}
+	return tx
+}
+
+// Substitute: 확인 없이 범위 안의 매치를 모두 바꿈. 바꾼 개수를 돌려줌
+func (sp *SyncProtocol) Substitute(in commander.SubstituteInput) (int, error) {
+	plan, err := sp.planReplace(in)
+	if err != nil {
+		return 0, err
+	}
+	return sp.applyReplace(plan, func(int) bool { return true }), nil
+}
+
+// ReplaceSession: 매치마다 확인하며 치환 (s///c)
+// 확인하는 동안 문서는 그대로 두고, 끝날 때 수락한 것만 한 번에 바꿈
+type ReplaceSession struct {
+	sp       *SyncProtocol
+	plan     *replacePlan
+	index    int
+	accepted []bool
+}
+
+// BeginReplace: 확인 치환 시작. 매치가 없으면 Done()이 바로 true
+func (sp *SyncProtocol) BeginReplace(in commander.SubstituteInput) (*ReplaceSession, error) {
+	if sp.large != nil {
+		return nil, storage.ErrReadOnly
+	}
+	plan, err := sp.planReplace(in)
+	if err != nil {
+		return nil, err
+	}
+	rs := &ReplaceSession{sp: sp, plan: plan, accepted: make([]bool, len(plan.matches))}
+	sp.withCursorHidden(func() {
+		sp.clearSearchHighlights()
+		sp.search = &searchState{current: -1}
+		rs.showCurrent()
+	})
+	return rs, nil
+}
+
+// Done: 더 물어볼 매치가 없음
+func (rs *ReplaceSession) Done() bool {
+	return rs.index >= len(rs.plan.matches)
+}
+
+// Progress: 현재 매치 번호(1부터)와 전체 개수
+func (rs *ReplaceSession) Progress() (current, total int) {
+	return min(rs.index+1, len(rs.plan.matches)), len(rs.plan.matches)
+}
+
+// Current: 지금 물어보는 매치와 바뀔 문자열
+func (rs *ReplaceSession) Current() (match, replacement string) {
+	if rs.Done() {
+		return "", ""
+	}
+	m := rs.plan.matches[rs.index]
+	return rs.plan.text[m[0]:m[1]], rs.plan.expand(rs.index)
+}
+
+// Answer: 현재 매치를 바꿀지(y) 말지(n) 정하고 다음 매치로
+func (rs *ReplaceSession) Answer(accept bool) {
+	if rs.Done() {
+		return
+	}
+	rs.accepted[rs.index] = accept
+	rs.index++
+	rs.sp.withCursorHidden(rs.showCurrent)
+}
+
+// AcceptAll: 남은 매치를 모두 바꿈 (a)
+func (rs *ReplaceSession) AcceptAll() {
+	for ; rs.index < len(rs.accepted); rs.index++ {
+		rs.accepted[rs.index] = true
+	}
+}
+
+// Finish: 강조를 지우고 지금까지 수락한 매치만 적용 (q, ESC도 여기로)
+func (rs *ReplaceSession) Finish() int {
+	sp := rs.sp
+	sp.withCursorHidden(func() {
+		sp.clearSearchHighlights()
+		sp.search = nil
+	})
+	return sp.applyReplace(rs.plan, func(i int) bool { return rs.accepted[i] })
+}
+
+// showCurrent: 현재 매치를 강조하고 커서를 그 시작으로 (여러 줄 매치는 줄마다 강조)
+func (rs *ReplaceSession) showCurrent() {
+	sp := rs.sp
+	s := sp.search
+	prev := s.byNode
+	s.byNode = map[*SyncNode][]span{}
+	if !rs.Done() {
+		m := rs.plan.matches[rs.index]
+		start, end := rs.plan.posOf(m[0]), rs.plan.posOf(m[1])
+		for line := start.line; line <= end.line; line++ {
+			node, found := sp.doc.data.findNode(uint(line))
+			if !found {
+				break
+			}
+			hl := span{0, node.PieceTable.Length()}
+			if line == start.line {
+				hl.start = start.inset
+			}
+			if line == end.line {
+				hl.end = end.inset
+			}
+			if hl.start < hl.end {
+				s.byNode[node] = []span{hl}
+			}
+		}
+		if node, found := sp.doc.data.findNode(uint(start.line)); found {
+			sp.moveCursorTo(node, start.inset)
+		}
+	}
+	for sn := range prev {
+		sp.syncNode(sn)
+	}
+	for sn := range s.byNode {
+		sp.syncNode(sn)
+	}
+}
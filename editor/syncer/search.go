@@ -0,0 +1,299 @@
+package syncer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 테마가 없을 때의 검색 매치 배경색
+const defaultMatchColor = 0xFFFFE08A
+
+// span: 한 라인 안의 글자 인셋 범위 [start, end)
+type span struct {
+	start, end int
+}
+
+// searchMatch: 문서 안의 매치 하나
+type searchMatch struct {
+	node *SyncNode
+	span
+}
+
+// searchState: 진행중인 검색 (쿼리, 매치 목록, 시작 위치)
+type searchState struct {
+	query   string
+	matches []searchMatch        // 문서 순서
+	byNode  map[*SyncNode][]span // 라인 그릴 때 강조할 범위
+	current int                  // matches 안의 현재 매치 (-1이면 없음)
+	stale   bool                 // 문서가 바뀌어서 매치를 다시 찾아야 함 (markChanged로 표시)
+
+	// 검색을 시작한 커서 위치 (취소하면 돌아감)
+	originNode  *SyncNode
+	originInset int
+	// 대용량 파일 모드에서는 창이 바뀌면 노드가 사라지므로 파일 라인 번호로 기억
+	originLine int
+}
+
+// StartSearch: 검색 프롬프트를 열 때 호출. 현재 커서 위치를 기억
+func (sp *SyncProtocol) StartSearch() {
+	sp.withCursorHidden(func() {
+		sp.clearSearchHighlights()
+		sp.search = &searchState{
+			current:     -1,
+			originNode:  sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer),
+			originInset: sp.cursor.currentCharInset,
+			originLine:  sp.cursorFilePos().Line,
+		}
+	})
+}
+
+// UpdateSearch: 쿼리가 바뀔 때마다 호출 (증분 검색)
+// 시작 위치 이후의 첫 매치로 커서를 옮기고, 없으면 문서 처음부터 찾음
+func (sp *SyncProtocol) UpdateSearch(query string) {
+	if sp.search == nil {
+		sp.StartSearch()
+	}
+	sp.withCursorHidden(func() {
+		sp.search.query = query
+		sp.recomputeMatches()
+		s := sp.search
+		if sp.large != nil {
+			origin := Position{Line: s.originLine, Col: s.originInset}
+			if found, _ := sp.findLarge(origin, true, true); !found {
+				sp.showLine(origin.Line, origin.Col)
+			}
+			return
+		}
+		if len(s.matches) == 0 {
+			sp.moveCursorTo(s.originNode, s.originInset)
+			return
+		}
+		s.current = sp.matchAfter(s.originNode, s.originInset, true)
+		m := s.matches[s.current]
+		sp.moveCursorTo(m.node, m.start)
+	})
+}
+
+// FindNext: 다음(forward) / 이전 매치로 이동. 끝에서는 반대쪽으로 넘어감
+// 넘어갔으면 wrapped=true
+func (sp *SyncProtocol) FindNext(forward bool) (found, wrapped bool) {
+	s := sp.search
+	if sp.large != nil && s != nil {
+		// 창 밖의 매치는 매치 목록에 없으므로 파일에서 바로 찾음
+		sp.withCursorHidden(func() {
+			found, wrapped = sp.findLarge(sp.cursorFilePos(), forward, false)
+		})
+		return found, wrapped
+	}
+	if s == nil || len(s.matches) == 0 {
+		return false, false
+	}
+	sp.withCursorHidden(func() {
+		node := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+		line, inset := sp.doc.data.findOrder(node), sp.cursor.currentCharInset
+		next := sp.matchAfter(node, inset, false)
+		if !forward {
+			next = sp.matchBefore(node, inset)
+		}
+		// 커서 기준으로 반대쪽 매치로 갔으면 문서 끝을 넘어간 것
+		m := s.matches[next]
+		mLine := sp.doc.data.findOrder(m.node)
+		if forward {
+			wrapped = mLine < line || (mLine == line && m.start <= inset)
+		} else {
+			wrapped = mLine > line || (mLine == line && m.start >= inset)
+		}
+		s.current = next
+		sp.moveCursorTo(m.node, m.start)
+	})
+	return true, wrapped
+}
+
+// EndSearch: 검색 프롬프트를 닫을 때 호출
+// keep이면 현재 위치/강조를 유지 (F3으로 계속 이동), 아니면 시작 위치로 돌아가고 강조 해제
+func (sp *SyncProtocol) EndSearch(keep bool) {
+	s := sp.search
+	if s == nil {
+		return
+	}
+	if keep && s.query != "" {
+		return
+	}
+	sp.withCursorHidden(func() {
+		if !keep && sp.large != nil {
+			sp.showLine(s.originLine, s.originInset)
+		} else if !keep && s.originNode != nil && sp.doc.data.findOrder(s.originNode) >= 0 {
+			sp.moveCursorTo(s.originNode, s.originInset)
+		}
+		sp.clearSearchHighlights()
+		sp.search = nil
+	})
+}
+
+// SearchStatus: 현재 매치 번호(1부터, 없으면 0)와 전체 매치 수
+// 대용량 파일 모드에서는 지금 만들어진 창 안에서의 번호/수
+func (sp *SyncProtocol) SearchStatus() (current, total int) {
+	if sp.search == nil {
+		return 0, 0
+	}
+	return sp.search.current + 1, len(sp.search.matches)
+}
+
+// SearchQuery: 진행중인 검색어 (없으면 빈 문자열)
+func (sp *SyncProtocol) SearchQuery() string {
+	if sp.search == nil {
+		return ""
+	}
+	return sp.search.query
+}
+
+// refreshSearch: 명령 실행 뒤 호출. 문서가 바뀌었으면 매치를 다시 계산 (커서는 그대로)
+func (sp *SyncProtocol) refreshSearch() {
+	if sp.search == nil || sp.search.query == "" {
+		return
+	}
+	s := sp.search
+	if s.stale {
+		sp.withCursorHidden(sp.recomputeMatches)
+	}
+	// 커서에 가장 가까운 다음 매치를 현재로
+	if len(s.matches) == 0 {
+		s.current = -1
+		return
+	}
+	node := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	s.current = sp.matchAfter(node, sp.cursor.currentCharInset, true)
+}
+
+// recomputeMatches: 모든 노드의 PieceTable에서 쿼리를 찾고, 강조가 바뀐 라인만 다시 그림
+func (sp *SyncProtocol) recomputeMatches() {
+	s := sp.search
+	prev := s.byNode
+	s.matches = nil
+	s.byNode = map[*SyncNode][]span{}
+	s.current = -1
+	s.stale = false
+	if s.query != "" {
+		sp.doc.data.ForEach(func(sn *SyncNode) {
+			for _, m := range findAll(sn.PieceTable.String(), s.query) {
+				s.matches = append(s.matches, searchMatch{node: sn, span: m})
+				s.byNode[sn] = append(s.byNode[sn], m)
+			}
+		})
+	}
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		if !sameSpans(prev[sn], s.byNode[sn]) {
+			sp.syncNode(sn)
+		}
+	})
+}
+
+// findAll: text에서 query의 겹치지 않는 모든 위치 (글자 인셋)
+func findAll(text, query string) []span {
+	var spans []span
+	queryLen := utf8.RuneCountInString(query)
+	offset, inset := 0, 0
+	for {
+		i := strings.Index(text[offset:], query)
+		if i < 0 {
+			return spans
+		}
+		inset += utf8.RuneCountInString(text[offset : offset+i])
+		spans = append(spans, span{inset, inset + queryLen})
+		inset += queryLen
+		offset += i + len(query)
+	}
+}
+
+func sameSpans(a, b []span) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAfter: (node, inset) 이후의 첫 매치 (inclusive면 그 위치의 매치도 포함). 없으면 처음으로
+func (sp *SyncProtocol) matchAfter(node *SyncNode, inset int, inclusive bool) int {
+	line := sp.doc.data.findOrder(node)
+	for i, m := range sp.search.matches {
+		mLine := sp.doc.data.findOrder(m.node)
+		if mLine > line || (mLine == line && (m.start > inset || (inclusive && m.start == inset))) {
+			return i
+		}
+	}
+	return 0
+}
+
+// matchBefore: (node, inset) 이전의 마지막 매치. 없으면 끝으로
+func (sp *SyncProtocol) matchBefore(node *SyncNode, inset int) int {
+	line := sp.doc.data.findOrder(node)
+	for i := len(sp.search.matches) - 1; i >= 0; i-- {
+		m := sp.search.matches[i]
+		mLine := sp.doc.data.findOrder(m.node)
+		if mLine < line || (mLine == line && m.start < inset) {
+			return i
+		}
+	}
+	return len(sp.search.matches) - 1
+}
+
+// clearSearchHighlights: 강조된 라인들을 원래대로 다시 그림
+func (sp *SyncProtocol) clearSearchHighlights() {
+	if sp.search == nil {
+		return
+	}
+	old := sp.search.byNode
+	sp.search.byNode = nil
+	for sn := range old {
+		if sp.doc.data.findOrder(sn) >= 0 {
+			sp.syncNode(sn)
+		}
+	}
+}
+
+// lineHighlights: 노드에서 배경을 강조할 범위 (선택 영역 + 검색 매치)
+func (sp *SyncProtocol) lineHighlights(sn *SyncNode) []span {
+	var spans []span
+	if s, ok := sp.selectionSpans[sn]; ok {
+		spans = append(spans, s)
+	}
+	if sp.search != nil {
+		spans = append(spans, sp.search.byNode[sn]...)
+	}
+	return spans
+}
+
+// matchColor: 검색 매치 배경색
+func (sp *SyncProtocol) matchColor() uint32 {
+	if sp.theme != nil {
+		return uint32(sp.theme.Selection)
+	}
+	return defaultMatchColor
+}
+
+// moveCursorTo: 커서를 노드/인셋으로 옮기고 현재 라인 강조, 뷰포트 갱신
+// 커서가 그려져 있지 않은 상태에서 불러야 함 (withCursorHidden)
+func (sp *SyncProtocol) moveCursorTo(node *SyncNode, inset int) {
+	if node == nil || sp.doc.data.findOrder(node) < 0 {
+		return
+	}
+	sp.cursor.currentLineBuffer = node.LineBuffer
+	sp.cursor.currentCharInset = min(inset, node.PieceTable.Length())
+	sp.refreshCurrentLine()
+	sp.ensureCursorVisible()
+}
+
+// withCursorHidden: 라인버퍼를 다시 그리는 동안 커서를 지웠다가 원래대로 돌려놓음
+func (sp *SyncProtocol) withCursorHidden(fn func()) {
+	wasVisible := sp.cursor.visible
+	sp.cursor.ClearCursor(sp)
+	fn()
+	if wasVisible {
+		sp.cursor.CusorDrawOn(sp)
+	}
+}
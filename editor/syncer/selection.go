@@ -0,0 +1,123 @@
+package syncer
+
+import "unicode/utf8"
+
+// docPos: 문서 안의 위치 (0-based 라인, 글자 인셋)
+type docPos struct {
+	line, inset int
+}
+
+func (p docPos) before(q docPos) bool {
+	return p.line < q.line || (p.line == q.line && p.inset < q.inset)
+}
+
+// markState: 선택 시작점. 선택 영역은 mark ~ 커서
+type markState struct {
+	node  *SyncNode
+	inset int
+}
+
+// SetMark: 현재 커서 위치를 선택 시작점으로 (이미 있으면 선택 해제)
+func (sp *SyncProtocol) SetMark() (active bool) {
+	sp.withCursorHidden(func() {
+		if sp.mark != nil {
+			sp.mark = nil
+		} else {
+			sp.mark = &markState{
+				node:  sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer),
+				inset: sp.cursor.currentCharInset,
+			}
+		}
+		sp.refreshSelection()
+	})
+	return sp.mark != nil
+}
+
+// ClearMark: 선택 해제
+func (sp *SyncProtocol) ClearMark() {
+	if sp.mark == nil {
+		return
+	}
+	sp.withCursorHidden(func() {
+		sp.mark = nil
+		sp.refreshSelection()
+	})
+}
+
+// HasSelection: 선택 영역이 있는지
+func (sp *SyncProtocol) HasSelection() bool {
+	_, _, ok := sp.selectionRange()
+	return ok
+}
+
+// cursorPos: 커서의 문서 위치
+func (sp *SyncProtocol) cursorPos() docPos {
+	return docPos{sp.cursorLineIndex(), sp.cursor.currentCharInset}
+}
+
+// selectionRange: 선택 영역 [start, end). mark가 없거나 지워진 노드면 false
+func (sp *SyncProtocol) selectionRange() (start, end docPos, ok bool) {
+	if sp.mark == nil {
+		return docPos{}, docPos{}, false
+	}
+	markLine := sp.doc.data.findOrder(sp.mark.node)
+	if markLine < 0 {
+		return docPos{}, docPos{}, false
+	}
+	start = docPos{markLine, min(sp.mark.inset, sp.mark.node.PieceTable.Length())}
+	end = sp.cursorPos()
+	if end.before(start) {
+		start, end = end, start
+	}
+	return start, end, start != end
+}
+
+// refreshSelection: 선택 강조가 바뀐 라인만 다시 그림 (커서가 지워진 상태에서 호출)
+func (sp *SyncProtocol) refreshSelection() {
+	prev := sp.selectionSpans
+	sp.selectionSpans = map[*SyncNode]span{}
+	if start, end, ok := sp.selectionRange(); ok {
+		line := 0
+		sp.doc.data.ForEach(func(sn *SyncNode) {
+			if line >= start.line && line <= end.line {
+				s := span{0, sn.PieceTable.Length()}
+				if line == start.line {
+					s.start = start.inset
+				}
+				if line == end.line {
+					s.end = end.inset
+				}
+				if s.start < s.end {
+					sp.selectionSpans[sn] = s
+				}
+			}
+			line++
+		})
+	}
+	for sn := range prev {
+		if _, still := sp.selectionSpans[sn]; !still && sp.doc.data.findOrder(sn) >= 0 {
+			sp.syncNode(sn)
+		}
+	}
+	for sn, s := range sp.selectionSpans {
+		if prev[sn] != s {
+			sp.syncNode(sn)
+		}
+	}
+}
+
+// runeOffset: text의 inset번째 글자의 바이트 위치 (넘으면 len(text))
+func runeOffset(text string, inset int) int {
+	for i := range text {
+		if inset == 0 {
+			return i
+		}
+		inset--
+	}
+	return len(text)
+}
+
+// insetOf: text의 바이트 위치 -> 글자 인셋
+func insetOf(text string, offset int) int {
+	return utf8.RuneCountInString(text[:offset])
+}
@@ -0,0 +1,195 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go_editor/editor/charset"
+	glp "go_editor/editor/screener/glyph"
+	"go_editor/editor/storage"
+)
+
+// 상태 표시줄 메시지가 보이는 시간
+const messageTimeout = 5 * time.Second
+
+// Status: 상태 표시줄에 보여줄 문서 상태
+type Status struct {
+	FileName   string
+	Dirty      bool
+	Line       int // 1부터
+	Column     int // 1부터, 글자 단위
+	TotalLines int
+	Encoding   string
+	EOL        string // LF, CRLF
+	Mode       string // 에디터가 채움 (EDIT, RELOAD? 등)
+	Message    string // 시간이 지나면 빈 문자열
+	// 대용량 파일의 줄 색인중이면 true (TotalLines는 지금까지 센 수)와 진행률 (%)
+	Indexing       bool
+	IndexedPercent int
+}
+
+// SetMessage: 상태 표시줄에 잠깐 보여줄 메시지
+// 글리프가 ASCII만 있으므로 메시지는 영어로 씀
+func (sp *SyncProtocol) SetMessage(format string, args ...any) {
+	sp.message = fmt.Sprintf(format, args...)
+	sp.messageAt = time.Now()
+}
+
+// 상태 표시줄에 보여줄 에러별 영어 문구
+var statusErrors = []struct {
+	err  error
+	text string
+}{
+	{storage.ErrReadOnly, "file is read-only"},
+	{fs.ErrNotExist, "file not found"},
+	{fs.ErrPermission, "permission denied"},
+	{charset.ErrUnrepresentable, "text has characters the encoding cannot hold"},
+	{ErrUnsaved, "unsaved changes, save first"},
+	{ErrOutOfRange, "out of range"},
+}
+
+// StatusError: 에러를 상태 표시줄에 넣을 문구로
+// 에러 문구는 한국어가 많아 그대로 넣으면 그릴 수 없으므로 아는 에러는 영어로 바꾸고,
+// 그 밖의 ASCII가 아닌 문구는 로그를 보라고만 함 (원래 에러는 호출한 쪽이 로그에 남김)
+func StatusError(err error) string {
+	for _, e := range statusErrors {
+		if errors.Is(err, e.err) {
+			return e.text
+		}
+	}
+	if msg := err.Error(); isASCII([]byte(msg)) {
+		return msg
+	}
+	return "see log for details"
+}
+
+// Status: 현재 문서 상태
+func (sp *SyncProtocol) Status() Status {
+	st := Status{
+		FileName:   storageName(sp.storage),
+		Dirty:      sp.dirty,
+		Line:       sp.lineBase() + sp.cursorLineIndex() + 1,
+		Column:     sp.cursor.currentCharInset + 1,
+		TotalLines: sp.LineCount(),
+		Encoding:   sp.encoding.String(),
+		EOL:        "LF",
+	}
+	if sp.large != nil {
+		lines, percent, done := sp.large.index.progress()
+		st.TotalLines, st.IndexedPercent, st.Indexing = lines, percent, !done
+	}
+	if sp.eol == EOLCRLF {
+		st.EOL = "CRLF"
+	}
+	if sp.message != "" && time.Since(sp.messageAt) < messageTimeout {
+		st.Message = sp.message
+	}
+	return st
+}
+
+// StatusBarHeight: 상태 표시줄 픽셀 높이 (확대 배율과 무관)
+func (sp *SyncProtocol) StatusBarHeight() int {
+	return sp.baseLineHeight
+}
+
+// statusText: 왼쪽(파일/모드/메시지), 오른쪽(위치/인코딩) 문자열
+func (st Status) statusText() (left, right string) {
+	name := st.FileName
+	if st.Dirty {
+		name += " [+]"
+	}
+	parts := []string{name}
+	if st.Mode != "" {
+		parts = append(parts, st.Mode)
+	}
+	if st.Message != "" {
+		parts = append(parts, st.Message)
+	}
+	left = " " + strings.Join(parts, " | ")
+	lines := fmt.Sprintf("%d lines", st.TotalLines)
+	if st.Indexing {
+		lines = fmt.Sprintf("%d+ lines (indexing %d%%)", st.TotalLines, st.IndexedPercent)
+	}
+	right = fmt.Sprintf("Ln %d, Col %d | %s | %s | %s ",
+		st.Line, st.Column, lines, st.Encoding, st.EOL)
+	return left, right
+}
+
+// statusColors: 상태 표시줄 글자/배경색 (테마가 없으면 반전)
+func (sp *SyncProtocol) statusColors() (fg, bg uint32) {
+	if sp.theme != nil {
+		return uint32(sp.theme.StatusBar.Foreground), uint32(sp.theme.StatusBar.Background)
+	}
+	return sp.bgColor, sp.fgColor
+}
+
+// newStatusPixels: 배경색으로 칠한 상태 표시줄 픽셀 (폭=screenWidth, 높이=StatusBarHeight)
+func (sp *SyncProtocol) newStatusPixels(bg uint32) []uint32 {
+	pixels := make([]uint32, sp.StatusBarHeight()*sp.screenWidth)
+	for i := range pixels {
+		pixels[i] = bg
+	}
+	return pixels
+}
+
+// drawStatusText: 상태 표시줄의 x부터 text를 그림 (limitX를 넘는 글자는 생략). 끝난 x를 돌려줌
+func (sp *SyncProtocol) drawStatusText(pixels []uint32, x, limitX int, text string, fg uint32) int {
+	height := sp.StatusBarHeight()
+	yOffset := (height - glp.GlyphHeight) / 2
+	for _, ch := range text {
+		if x+glp.GlyphWidth > limitX {
+			break
+		}
+		sp.drawGlyph(pixels, height, 1, x, yOffset, glyphFor(ch), fg)
+		x += glp.GlyphWidth
+	}
+	return x
+}
+
+// RenderStatusBar: 문서 라인버퍼와 별개인 상태 표시줄 픽셀
+// 공간이 모자라면 오른쪽(위치 정보)을 남기고 왼쪽을 자름
+func (sp *SyncProtocol) RenderStatusBar(st Status) []uint32 {
+	fg, bg := sp.statusColors()
+	pixels := sp.newStatusPixels(bg)
+
+	left, right := st.statusText()
+	columns := sp.screenWidth / glp.GlyphWidth
+	rightX := max(columns-utf8.RuneCountInString(right), 0) * glp.GlyphWidth
+	sp.drawStatusText(pixels, 0, rightX, left, fg)
+	sp.drawStatusText(pixels, rightX, sp.screenWidth, right, fg)
+	return pixels
+}
+
+// RenderPromptBar: 상태 표시줄 자리에 입력 프롬프트(명령줄 등)를 그림
+// cursor는 text 안의 글자 위치, 그 칸을 반전색으로 표시
+func (sp *SyncProtocol) RenderPromptBar(prompt, text string, cursor int) []uint32 {
+	fg, bg := sp.statusColors()
+	pixels := sp.newStatusPixels(bg)
+
+	// 커서가 화면 밖이면 앞부분을 잘라서 보여줌
+	runes := []rune(text)
+	columns := sp.screenWidth/glp.GlyphWidth - utf8.RuneCountInString(prompt) - 2
+	start := max(cursor-columns+1, 0)
+	runes, cursor = runes[start:], cursor-start
+
+	x := sp.drawStatusText(pixels, 0, sp.screenWidth, " "+prompt, fg)
+	cursorX := x + cursor*glp.GlyphWidth
+	height := sp.StatusBarHeight()
+	for row := 0; row < height; row++ {
+		for col := cursorX; col < cursorX+glp.GlyphWidth && col < sp.screenWidth; col++ {
+			pixels[row*sp.screenWidth+col] = fg
+		}
+	}
+	for i, ch := range runes {
+		color := fg
+		if i == cursor {
+			color = bg
+		}
+		x = sp.drawStatusText(pixels, x, sp.screenWidth, string(ch), color)
+	}
+	return pixels
+}
@@ -0,0 +1,171 @@
+package syncer
+
+import "strings"
+
+// TextStore: Document가 텍스트를 두는 방식
+//   - *SyncData: 라인마다 PieceTable을 가진 노드 리스트 (에디터 뷰가 쓰는 방식)
+//   - *PieceStore: 문서 전체를 PieceTable 하나에 두고 줄 위치는 조각 트리로 찾음 (화면 없이만 씀)
+//
+// 위치 검사와 변경 이벤트는 Document가 처리하므로 구현은 문서 안의 위치만 받고,
+// Insert/Delete의 text에는 \n 줄바꿈만 들어옴
+type TextStore interface {
+	LineCount() int
+	Line(i int) string
+	LineLength(i int) int
+	Lines() []string
+	Insert(line, col int, text string)
+	Delete(start, end Position)
+	Offset(pos Position) int
+	PositionAt(offset int) (Position, bool)
+}
+
+// ----------------------------------------------------
+// SyncData: 라인별 저장
+// ----------------------------------------------------
+
+func (sd *SyncData) LineCount() int {
+	count := 0
+	sd.ForEach(func(*SyncNode) {
+		count++
+	})
+	return count
+}
+
+func (sd *SyncData) Line(i int) string {
+	node, _ := sd.findNode(uint(i))
+	return node.PieceTable.String()
+}
+
+func (sd *SyncData) LineLength(i int) int {
+	node, _ := sd.findNode(uint(i))
+	return node.PieceTable.Length()
+}
+
+func (sd *SyncData) Lines() []string {
+	var lines []string
+	sd.ForEach(func(sn *SyncNode) {
+		lines = append(lines, sn.PieceTable.String())
+	})
+	return lines
+}
+
+// Insert: 줄바꿈이 있으면 col 뒤쪽은 마지막 조각과 함께 새 라인으로, 가운데 조각은 새 라인으로
+func (sd *SyncData) Insert(line, col int, text string) {
+	node, _ := sd.findNode(uint(line))
+	parts := strings.Split(text, "\n")
+	if len(parts) == 1 {
+		node.PieceTable.Insert(col, text)
+		return
+	}
+	rs := []rune(node.PieceTable.String())
+	sd.insertAfter(node, parts[len(parts)-1]+string(rs[col:]))
+	node.PieceTable.Delete(len(rs), len(rs)-col)
+	node.PieceTable.Insert(col, parts[0])
+	cur := node
+	for _, part := range parts[1 : len(parts)-1] {
+		cur = sd.insertAfter(cur, part)
+	}
+}
+
+// Delete: 여러 라인에 걸치면 가운데와 마지막 라인을 지우고 마지막 라인의 뒷부분을 첫 라인에 붙임
+func (sd *SyncData) Delete(start, end Position) {
+	first, _ := sd.findNode(uint(start.Line))
+	if start.Line == end.Line {
+		first.PieceTable.Delete(end.Col, end.Col-start.Col)
+		return
+	}
+	last, _ := sd.findNode(uint(end.Line))
+	rest := []rune(last.PieceTable.String())[end.Col:]
+	for first.next != last {
+		sd.deleteByPtr(first.next)
+	}
+	sd.deleteByPtr(last)
+	first.PieceTable.Delete(first.PieceTable.Length(), first.PieceTable.Length()-start.Col)
+	first.PieceTable.Insert(start.Col, string(rest))
+}
+
+func (sd *SyncData) Offset(pos Position) int {
+	offset, line := 0, 0
+	for node := sd.head; node != nil && line < pos.Line; node = node.next {
+		offset += node.PieceTable.Length() + 1
+		line++
+	}
+	return offset + pos.Col
+}
+
+func (sd *SyncData) PositionAt(offset int) (Position, bool) {
+	line := 0
+	for node := sd.head; node != nil; node = node.next {
+		length := node.PieceTable.Length()
+		if offset <= length {
+			return Position{Line: line, Col: offset}, true
+		}
+		offset -= length + 1
+		line++
+	}
+	return Position{}, false
+}
+
+// ----------------------------------------------------
+// PieceStore: 문서 전체를 PieceTable 하나에
+// ----------------------------------------------------
+
+// PieceStore: 문서 전체가 PieceTable 하나인 저장 방식
+// 줄 시작 위치는 조각 트리에 캐시한 줄바꿈 수로 O(log n)에 찾으므로 줄 인덱스를 따로 고치지 않음
+// 줄바꿈도 보통 글자라서 Enter/백스페이스가 라인을 나누거나 합치지 않고, 큰 붙여넣기도 조각 하나
+// 라인버퍼를 달 노드가 없으므로 뷰를 붙일 수 없음 (NewView는 ErrNotLineStore)
+type PieceStore struct {
+	table *PieceTable
+}
+
+// NewPieceStore: text로 생성 (\r\n은 \n으로)
+func NewPieceStore(text string) *PieceStore {
+	return &PieceStore{table: NewPieceTable(strings.Join(splitLines(text), "\n"))}
+}
+
+// bounds: i번째 줄의 [start, end) 글자 위치 (줄바꿈 제외)
+func (ps *PieceStore) bounds(i int) (start, end int) {
+	start = ps.table.LineStart(i)
+	if i+1 < ps.table.LineCount() {
+		return start, ps.table.LineStart(i+1) - 1
+	}
+	return start, ps.table.Length()
+}
+
+func (ps *PieceStore) LineCount() int {
+	return ps.table.LineCount()
+}
+
+func (ps *PieceStore) Line(i int) string {
+	return ps.table.Substring(ps.bounds(i))
+}
+
+func (ps *PieceStore) LineLength(i int) int {
+	start, end := ps.bounds(i)
+	return end - start
+}
+
+func (ps *PieceStore) Lines() []string {
+	return strings.Split(ps.table.String(), "\n")
+}
+
+func (ps *PieceStore) Insert(line, col int, text string) {
+	ps.table.Insert(ps.table.LineStart(line)+col, text)
+}
+
+func (ps *PieceStore) Delete(start, end Position) {
+	from, to := ps.Offset(start), ps.Offset(end)
+	ps.table.Delete(to, to-from)
+}
+
+func (ps *PieceStore) Offset(pos Position) int {
+	return ps.table.LineStart(pos.Line) + pos.Col
+}
+
+func (ps *PieceStore) PositionAt(offset int) (Position, bool) {
+	if offset > ps.table.Length() {
+		return Position{}, false
+	}
+	line := ps.table.LineAt(offset)
+	return Position{Line: line, Col: offset - ps.table.LineStart(line)}, true
+}
@@ -1,8 +1,13 @@
 package syncer
 
 import (
+	"go_editor/editor/charset"
 	"go_editor/editor/commander"
 	glp "go_editor/editor/screener/glyph"
+	"go_editor/editor/storage"
+	"go_editor/editor/theme"
+	"log/slog"
+	"time"
 )
 
 // ----------------------------------------------------
@@ -13,75 +18,275 @@ import (
 type SyncProtocol struct {
 	screenWidth  int
 	screenHeight int
-	LineHeight   int
-
-	fgColor uint32
-	bgColor uint32
-	cursor  *Cursor
-
-	syncData      *SyncData
-	SyncStateCode SyncStateCode
-	changedNode   *SyncNode
+	LineHeight   int // 배율이 적용된 라인 높이
+
+	// 확대 배율과 배율 1일 때의 라인 높이
+	scale          int
+	baseLineHeight int
+	// 화면 맨 위에 보이는 노드 순번 (스크롤)
+	viewTop int
+	// 줄 번호 거터 (폭은 픽셀, 텍스트는 그만큼 오른쪽에서 시작)
+	gutterMode  GutterMode
+	gutterWidth int
+
+	fgColor  uint32
+	bgColor  uint32
+	cursor   *Cursor
+	tabWidth int
+
+	// 색 테마 (nil이면 fg/bg만 사용)
+	theme *theme.Theme
+	// 현재 라인 색으로 그려진 노드
+	highlightedNode *SyncNode
+
+	// 그리는 문서 (노드 리스트와 변경 구독자)
+	doc *Document
+	// 대용량 파일 모드 (nil이면 일반 모드). 문서에는 파일의 일부 줄만 있음
+	large *largeFile
+
+	// 문서를 읽고 쓰는 저장소 (파일, 메모리, gzip 등)
+	storage storage.Storage
+	// 저장할 때 쓸 텍스트 인코딩 (불러올 때 감지된 값)
+	encoding charset.Encoding
+	// 저장할 때 쓸 줄바꿈 (EOLLF, EOLCRLF)
+	eol string
+
+	// 진행중인 검색 (nil이면 검색 안 함)
+	search *searchState
+	// 선택 시작점 (nil이면 선택 없음)과 선택 강조가 그려진 범위
+	mark           *markState
+	selectionSpans map[*SyncNode]span
+
+	// 되돌리기 / 다시 실행
+	undoStack, redoStack []*opTx
+	undoGroupOpen        bool // 연속 입력을 한 번에 되돌리기 위해 묶는 중
+	lastEditCode         commander.CommandCode
+
+	// 상태 표시줄에 잠깐 보여줄 메시지
+	message   string
+	messageAt time.Time
+
+	// 마지막 로드/저장 이후 수정 여부
+	dirty bool
+	// 마지막으로 읽거나 쓴 파일 내용 (외부 변경 감지용)
+	disk diskFingerprint
+	// 불러올 때 만든 매핑의 해제 함수 (매핑하지 않았으면 nil)
+	unmap func() error
+
+	logger *slog.Logger
 }
 
+// 설정이 없을 때의 탭 폭
+const defaultTabWidth = 4
+
 // ----------------------------------------------------
 // (3) SyncProtocol 생성자
 // ----------------------------------------------------
 // TODO 추후 "스크린스펙"받는 로직으로 변경
-func NewSyncProtocol(screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
-	lineCount := screenHeight / LineHeight
-	syncData := &SyncData{}
-	// 우선은 라인의 텍스트를 빈 문자열로 다 초기화 해 둚
-	//다만 이 단계에선 아직 텍스트-라인 버퍼간 싱크가 없음
-	for range lineCount {
-		syncData.insertNode(0, "")
+func NewSyncProtocol(st storage.Storage, screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
+	// 빈 문서 위에 뷰를 붙임
+	return newView(NewDocument(""), st, screenWidth, screenHeight, fg, bg, LineHeight)
+}
+
+// NewView: doc을 그리는 에디터 뷰. 모든 노드의 라인버퍼를 만듦
+// doc에 라인을 더하지 않음. 문서가 화면보다 짧으면 남는 자리는 스크리너가 배경색으로 채움
+// 이후 doc의 Insert/Delete도 화면에 반영됨. 라인버퍼가 노드에 달리므로 한 문서에 뷰는 하나
+// 뷰는 라인별 노드를 직접 다루므로 라인별 저장 문서에만 붙음. PieceStore 문서는 화면 없이만 쓰며
+// 저장 방식을 바꾸지 않고 ErrNotLineStore를 돌려줌
+func NewView(doc *Document, st storage.Storage, screenWidth, screenHeight int, fg, bg uint32, LineHeight int) (*SyncProtocol, error) {
+	if doc.data == nil {
+		return nil, ErrNotLineStore
 	}
+	return newView(doc, st, screenWidth, screenHeight, fg, bg, LineHeight), nil
+}
 
+// newView: 라인별 저장 문서(doc.data != nil)에 뷰를 붙임
+func newView(doc *Document, st storage.Storage, screenWidth, screenHeight int, fg, bg uint32, LineHeight int) *SyncProtocol {
 	sp := &SyncProtocol{
-		screenWidth:   screenWidth,
-		screenHeight:  screenHeight,
-		LineHeight:    LineHeight,
-		fgColor:       fg,
-		bgColor:       bg,
-		syncData:      syncData,
-		SyncStateCode: NodeModified,
-		changedNode:   nil,
-		cursor:        NewCursor(2, glp.GlyphHeight, 0xFF000000),
-	}
+		screenWidth:    screenWidth,
+		screenHeight:   screenHeight,
+		LineHeight:     LineHeight,
+		scale:          1,
+		baseLineHeight: LineHeight,
+		fgColor:        fg,
+		bgColor:        bg,
+		doc:            doc,
+		cursor:         NewCursor(2, glp.GlyphHeight, 0xFF000000),
+		tabWidth:       defaultTabWidth,
+		storage:        st,
+		encoding:       charset.UTF8,
+		eol:            EOLLF,
+		logger:         defaultLogger,
+	}
+	doc.view = sp
 
 	//여기서 워킹 통해서 각 노드마다 싱크 맞춰줌
-	syncData.ForEach(func(sn *SyncNode) {
+	doc.data.ForEach(func(sn *SyncNode) {
 		sp.syncNode(sn)
-
 	})
 
 	//커서 위치 0,0으로 이동
-	sp.cursor.currentLineBuffer = sp.syncData.head.LineBuffer
+	sp.cursor.currentLineBuffer = doc.data.head.LineBuffer
 	sp.cursor.currentCharInset = 0
-	println("lineCount", lineCount, "만큼 초기화")
 	return sp
 }
 
+// Document: 뷰가 그리는 문서
+func (sp *SyncProtocol) Document() *Document {
+	return sp.doc
+}
+
 // ProcessCommand는 에디터에서 최종 호출해서 명령어 처리함
 func (sp *SyncProtocol) ProcessCommand(cmd commander.Command) (
 	isContinue bool) {
-	opSequences, isContinue := sp.buildOpSequences(cmd)
+	if sp.large != nil {
+		if sp.large.rejectsCommand(cmd) {
+			sp.SetMessage("Large file is read-only")
+			return true
+		}
+		// 명령 전후로 커서 주변의 줄을 만들어 둠 (창 끝에서 커서가 막히지 않게)
+		sp.followCursor()
+		defer sp.followCursor()
+	}
+	if sp.processFileCommand(cmd) {
+		return true
+	}
+	if sp.processJumpCommand(cmd) {
+		return true
+	}
+	opSequences, isContinue, isEdit := sp.buildOpSequences(cmd)
 	if !isContinue {
 		return false
 	}
+	sp.runOps(cmd, opSequences, isEdit)
+	return true
+
+}
+
+// ProcessCommands는 한 번에 들어온 명령들을 처리함
+// 연속된 글자 입력은 한 시퀀스로 빌드해서 최적화 패스가 TypeText 하나로 묶게 함
+func (sp *SyncProtocol) ProcessCommands(cmds []commander.Command) (isContinue bool) {
+	for i := 0; i < len(cmds); {
+		j := i
+		for sp.large == nil && j < len(cmds) && cmds[j].IsTyping() {
+			j++
+		}
+		if j-i > 1 {
+			sp.processTyping(cmds[i:j])
+			i = j
+			continue
+		}
+		if !sp.ProcessCommand(cmds[i]) {
+			return false
+		}
+		i++
+	}
+	return true
+}
 
-	opSequences.ExecuteAll(sp)
+// processTyping: 글자 입력은 노드 구조와 가드를 바꾸지 않으므로 실행 없이 이어서 빌드 가능
+func (sp *SyncProtocol) processTyping(cmds []commander.Command) {
+	batch := NewOpSequences()
+	for _, cmd := range cmds {
+		ops, _, _ := sp.buildOpSequences(cmd)
+		batch.Append(ops.ops...)
+	}
+	sp.runOps(cmds[0], batch, true)
+}
+
+// runOps: 최적화 후 트랜잭션으로 실행하고 화면 상태 갱신
+func (sp *SyncProtocol) runOps(cmd commander.Command, opSequences *OpSequences, isEdit bool) {
+	program := optimize(opSequences)
+	sp.traceProgram(program)
+	tx, err := program.execute(sp)
+	if err != nil {
+		// 실행 전으로 롤백된 상태. 편집기는 계속 동작
+		sp.logger.Error("op 실행 실패", "err", err)
+		sp.SetMessage("Edit failed and was rolled back")
+		isEdit = false
+	}
+	if isEdit {
+		sp.recordEdit(cmd, tx)
+		// 변경 이벤트를 내지 않는 외부 op도 있어서 편집 명령이면 그대로 표시
+		sp.dirty = true
+	} else {
+		sp.breakUndoGroup()
+	}
+	sp.refreshCurrentLine()
+	sp.refreshSelection()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+}
 
+// processFileCommand는 문서 편집이 아닌 파일 단위 명령을 처리함
+// 처리한 명령이면 true (op 시퀀스를 만들지 않음)
+func (sp *SyncProtocol) processFileCommand(cmd commander.Command) bool {
+	switch cmd.Code {
+	case commander.CmdSave:
+		if err := sp.SaveToFile(); err != nil {
+			sp.logger.Warn("저장 실패", "err", err)
+			sp.SetMessage("Save failed: %s", StatusError(err))
+		}
+		return true
+	case commander.CmdReopenWithEncoding, commander.CmdSaveWithEncoding:
+	default:
+		return false
+	}
+	encInput, ok := cmd.Input.(commander.EncodingInput)
+	if !ok {
+		return true
+	}
+	enc, err := charset.Parse(encInput.Name)
+	if err != nil {
+		sp.logger.Warn("알 수 없는 인코딩", "err", err)
+		sp.SetMessage("Unknown encoding: %s", encInput.Name)
+		return true
+	}
+	if cmd.Code == commander.CmdReopenWithEncoding {
+		err = sp.ReopenWithEncoding(enc)
+	} else {
+		err = sp.SaveWithEncoding(enc)
+	}
+	if err != nil {
+		sp.logger.Warn("인코딩 변환 실패", "encoding", enc, "err", err)
+		sp.SetMessage("%s failed: %s", enc, StatusError(err))
+	}
 	return true
+}
+
+// 기본 빌더 등록
+func init() {
+	RegisterOpBuilder(commander.CmdExit, OpBuilder{Build: func(*SyncProtocol, commander.Command) (*OpSequences, bool) {
+		return nil, false
+	}})
+	RegisterOpBuilder(commander.CmdDelete, OpBuilder{Build: (*SyncProtocol).buildEditOps, Edit: true})
+	RegisterOpBuilder(commander.CmdInsert, OpBuilder{Build: (*SyncProtocol).buildEditOps, Edit: true})
+	RegisterOpBuilder(commander.CmdMove, OpBuilder{Build: func(sp *SyncProtocol, cmd commander.Command) (*OpSequences, bool) {
+		//커서 움직임만 있는 경우 노드 및 데이터의 변경이 존재 x
+		ops := NewOpSequences()
+		ops.Append(sp.buildCursorOp(cmd))
+		return ops, true
+	}})
+}
 
+// buildOpSequences는 등록된 빌더로 시퀀스 및 성공여부, 편집 여부 리턴
+// 빌더가 없는 명령은 빈 시퀀스
+func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (ops *OpSequences, isContinue, isEdit bool) {
+	b, ok := opBuilders[cmd.Code]
+	if !ok {
+		return NewOpSequences(), true, false
+	}
+	ops, isContinue = b.Build(sp, cmd)
+	return ops, isContinue, b.Edit
 }
 
-// interpretCommand는 시퀀스 및 성공여부 리턴턴
-func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (*opSequences, bool) {
+// buildEditOps는 삽입/삭제 명령의 시퀀스 빌드 (가드 클로스 처리)
+func (sp *SyncProtocol) buildEditOps(cmd commander.Command) (*OpSequences, bool) {
 
 	ops := NewOpSequences()
 	cursorLine, _ := sp.cursor.GetCoordinate()
-	currentNode := sp.syncData.findSyncNodeByLineBuffer(cursorLine)
+	currentNode := sp.doc.data.findSyncNodeByLineBuffer(cursorLine)
 
 	//노드가 있다면 피스테이블은 항상 같이 존재함
 	//그러나 라인버퍼는 존재를 모르므로 항상 주의
@@ -91,8 +296,6 @@ func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (*opSequences, b
 	var opCusror *OpCursor
 	textLen := currentNode.PieceTable.Length()
 	switch cmd.Code {
-	case commander.CmdExit:
-		return nil, false
 	case commander.CmdDelete:
 		if textLen == 0 {
 			//텍스트가 0인데 맨 위인 경우
@@ -161,15 +364,9 @@ func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (*opSequences, b
 		}
 	case commander.CmdInsert:
 		if charInput, ok := cmd.Input.(commander.CharInput); ok {
-			var co CursorOpCode
-			if currentNode.IsDownEnd() {
-				co = OpHoldCursor
-			} else {
-				co = OpDownLeftStartCursor
-			}
 			//엔터키 눌린 경우
 			if charInput.Char == commander.KeyEnter1 || charInput.Char == commander.KeyEnter2 {
-
+				// 라인 끝에서 나눠도 아래 라인이 생기므로 항상 아래 라인 맨 앞으로 (마지막 라인 포함)
 				opNodeGroup, opNodeText, opSync, opCusror = sp.buildTotalOpSequence(
 					OpSliceNodeAtGroup,
 					currentNode,
@@ -177,7 +374,7 @@ func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (*opSequences, b
 					rune(' '),
 					OpNodeSlicedSync,
 					currentNode,
-					co)
+					OpDownLeftStartCursor)
 			} else {
 				//그냥 문자열 인서트였을 경우
 				opNodeGroup, opNodeText, opSync, opCusror = sp.buildTotalOpSequence(
@@ -192,11 +389,9 @@ func (sp *SyncProtocol) buildOpSequences(cmd commander.Command) (*opSequences, b
 
 			}
 		}
-	case commander.CmdMove:
-		//커서 움직임만 있는 경우 노드 및 데이터의 변경이 존재 x
-		opCusror = sp.buildCursorOp(cmd)
 	}
 
+	// nil 포인터를 Op 인터페이스로 넘기지 않도록 하나씩 확인
 	if opNodeGroup != nil {
 		ops.Append(opNodeGroup)
 	}
@@ -227,7 +422,7 @@ func (sp *SyncProtocol) buildCursorOp(cmd commander.Command) *OpCursor {
 	cursorLine, charInset := sp.cursor.GetCoordinate()
 	//주의 할 것!! 커서는 항상 라인버퍼에 자신을 동기화함!
 	//그러나 이 경우엔 커서만 움직일 경우 상관 x
-	syncNode := sp.syncData.findSyncNodeByLineBuffer(cursorLine)
+	syncNode := sp.doc.data.findSyncNodeByLineBuffer(cursorLine)
 	textLen := syncNode.PieceTable.Length()
 	if charInput, ok := cmd.Input.(commander.CharInput); ok {
 		switch charInput.Char {
@@ -275,7 +470,7 @@ func (sp *SyncProtocol) syncNode(sn *SyncNode) {
 	str := sn.PieceTable.String()
 	if sn.LineBuffer == nil {
 		needToMove := false
-		if sp.syncData.findOrder(sn) == sp.syncData.findOrder(sp.syncData.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)) {
+		if sp.doc.data.findOrder(sn) == sp.doc.data.findOrder(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)) {
 			needToMove = true
 		}
 
@@ -286,7 +481,7 @@ func (sp *SyncProtocol) syncNode(sn *SyncNode) {
 		}
 	}
 
-	sp.ReflectLine(sn.LineBuffer, str)
+	sp.reflectLine(sn.LineBuffer, str, sp.lineBackground(sn), sp.lineHighlights(sn))
 }
 
 // SyncData: 요구사항에서 주어진 구조
@@ -315,7 +510,7 @@ func (sn *SyncNode) IsDownEnd() bool {
 	return sn.next == sn || sn.next == nil
 }
 
-// 편집 상태 코드 (추후 상세 동기화 시 사용)
+// 편집 상태 코드 (ChangeEvent의 종류)
 type SyncStateCode int
 
 const (
@@ -323,6 +518,8 @@ const (
 	NodeSliced
 	NodeDeleted
 	NodeModified
+	NodeMerged
+	DocumentReplaced
 )
 
 type ModifyCode int
@@ -430,9 +627,14 @@ func (sd *SyncData) insertByPtr(refNode *SyncNode, newData string) {
 	}
 }
 func (sd *SyncData) appendByPtr(refNode *SyncNode, newData string) *SyncNode {
+	return sd.appendPieceTable(refNode, NewPieceTable(newData))
+}
+
+// appendPieceTable: pt를 가진 새 노드를 refNode 뒤(리스트 끝)에 붙임
+func (sd *SyncData) appendPieceTable(refNode *SyncNode, pt *PieceTable) *SyncNode {
 	//Node를 refNode 뒤에 추가
 	newNode := &SyncNode{
-		PieceTable: NewPieceTable(newData),
+		PieceTable: pt,
 		LineBuffer: nil,
 		prev:       nil,
 		next:       nil,
@@ -450,6 +652,15 @@ func (sd *SyncData) appendByPtr(refNode *SyncNode, newData string) *SyncNode {
 	return newNode
 }
 
+// insertAfter: refNode 바로 뒤에 새 노드 삽입 (appendByPtr는 끝에 붙일 때만 씀)
+func (sd *SyncData) insertAfter(refNode *SyncNode, newData string) *SyncNode {
+	if refNode.next == nil {
+		return sd.appendByPtr(refNode, newData)
+	}
+	sd.insertByPtr(refNode.next, newData)
+	return refNode.next
+}
+
 // deleteByPtr(refNode):
 // refNode를 이중 연결 리스트에서 제거.
 func (sd *SyncData) deleteByPtr(refNode *SyncNode) {
@@ -509,17 +720,14 @@ func (sd *SyncData) sliceByPtr(refNode *SyncNode, char uint) {
 	}
 }
 
-// mergeNodeByPtr은 아예 새 문자열 생성하는 식으로 머징
+// mergeNodeByPtr은 PieceTable.Concat으로 머징 (슬라이스했던 라인이면 조각 구조 유지)
 // prev를 살리는 방식으로 머지합니다.
 func (sd *SyncData) mergeNodeByPtr(prev, next *SyncNode) {
 	if prev == nil || next == nil || prev.next != next || next.prev != prev {
 		return
 	}
 
-	prevStr := prev.PieceTable.String()
-	nextStr := next.PieceTable.String()
-	mergedStr := prevStr + nextStr
-	prev.PieceTable = NewPieceTable(mergedStr)
+	prev.PieceTable = prev.PieceTable.Concat(next.PieceTable)
 
 	if next.next != nil {
 		next.next.prev = prev
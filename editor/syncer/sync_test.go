@@ -1,9 +1,18 @@
 package syncer
 
 import (
+	"errors"
 	"fmt"
+	"go_editor/editor/charset"
 	"go_editor/editor/commander"
+	glp "go_editor/editor/screener/glyph"
+	"go_editor/editor/storage"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 // (4) 테스트 코드
@@ -12,41 +21,41 @@ import (
 // 테스트용 main 함수
 func TestSync(t *testing.T) {
 	// 1) 프로토콜 생성
-	sp := NewSyncProtocol(800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp := NewSyncProtocol(storage.NewMemoryStorage(nil), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
 
 	// 2) 초기 노드 2개 생성 (예시)
 	// 첫 번째 노드: "hello"
-	sp.syncData.insertNode(0, "hello")
+	sp.doc.data.insertNode(0, "hello")
 	// 두 번째 노드: "world"
-	sp.syncData.insertNode(0, "world")
+	sp.doc.data.insertNode(0, "world")
 	// 두 번째 노드의 PieceTable에 'w' 추가 예시
-	sp.syncData.modifyNode(1, 1, rune('t'), InsertASCII)
+	sp.doc.data.modifyNode(1, 1, rune('t'), InsertASCII)
 
 	fmt.Println("== 초기 리스트 ==")
-	PrintList(sp.syncData.head)
+	PrintList(sp.doc.data.head)
 
 	// 3) 첫 번째 노드("world")에서 인덱스 2를 기준으로 슬라이스
 	//    ("wo" | "rld") 예시
-	sp.syncData.sliceNode(0, 2)
+	sp.doc.data.sliceNode(0, 2)
 	fmt.Println("\n== sliceNode(0, 2) 호출 후 ==")
-	PrintList(sp.syncData.head)
+	PrintList(sp.doc.data.head)
 
 	// 4) 두 번째 노드("llo")를 삭제 (0-based: index=1)
-	sp.syncData.deleteNode(1)
+	sp.doc.data.deleteNode(1)
 	fmt.Println("\n== delete(1) 호출 후 (이중 연결 리스트) ==")
-	PrintList(sp.syncData.head)
+	PrintList(sp.doc.data.head)
 
-	sp.syncData.modifyNode(0, 1, rune('k'), InsertASCII)
+	sp.doc.data.modifyNode(0, 1, rune('k'), InsertASCII)
 	fmt.Println("\n== 모디파이1")
-	PrintList(sp.syncData.head)
+	PrintList(sp.doc.data.head)
 
-	sp.syncData.modifyNode(0, 1, rune(' '), DeleteASCII)
+	sp.doc.data.modifyNode(0, 1, rune(' '), DeleteASCII)
 	fmt.Println("\n== 모디파이2")
-	PrintList(sp.syncData.head)
+	PrintList(sp.doc.data.head)
 
 	// 5) 남은 리스트 역순 확인
 	fmt.Println("\n== 역순 확인 ==")
-	printListReverse(sp.syncData.head)
+	printListReverse(sp.doc.data.head)
 
 	// -----------------------------------------------------
 	// (추가) 커맨더 명령(Command) 직접 만들어서 테스트하기
@@ -57,7 +66,7 @@ func TestSync(t *testing.T) {
 	//TODO 이 두 줄의 커서로직을 추후 어캐처리할진 생각해보기
 	//TODO 우선은 0,0스타트 강제 위해서 이렇게 하는 중인데 (위의 로직이 너무 강제적이라서)
 	//TODO 일반적인 에디터 러닝에선 이런 강제 필요할지 생각해보고 지울지 결정.
-	sp.cursor.currentLineBuffer = sp.syncData.head.LineBuffer
+	sp.cursor.currentLineBuffer = sp.doc.data.head.LineBuffer
 	sp.cursor.currentCharInset = 0
 	// 예시로 8개 명령어 준비 (각 케이스당 2개씩)
 	testCommands := []commander.Command{
@@ -81,12 +90,12 @@ func TestSync(t *testing.T) {
 	println()
 	for i, cmd := range testCommands {
 		fmt.Printf("[Command #%d] -> Code=%v, Input=%v\n", i, cmd.Code, cmd.Input)
-		fmt.Printf("CursorLine %d, CursorInset %d \n", sp.syncData.findOrder(sp.syncData.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)), sp.cursor.currentCharInset)
+		fmt.Printf("CursorLine %d, CursorInset %d \n", sp.doc.data.findOrder(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)), sp.cursor.currentCharInset)
 		isContinue := sp.ProcessCommand(cmd)
 		fmt.Printf("   Processed => isContinue=%v\n", isContinue)
 
 		// 매번 실행 후, 리스트 상태 확인
-		PrintList(sp.syncData.head)
+		PrintList(sp.doc.data.head)
 
 		println("\n")
 	}
@@ -133,3 +142,1218 @@ func printListReverse(head *SyncNode) {
 		i++
 	}
 }
+
+// 메모리 저장소로 로드/저장 (디스크를 건드리지 않음)
+func TestLoadSaveWithMemoryStorage(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("first\nsecond"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'X'}})
+	if !sp.IsDirty() {
+		t.Fatal("입력 후 dirty가 아님")
+	}
+	if err := sp.SaveToFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := st.Load()
+	if string(data) != "Xfirst\nsecond" {
+		t.Fatalf("저장된 내용 = %q", data)
+	}
+	if sp.IsDirty() {
+		t.Fatal("저장 후에도 dirty")
+	}
+
+	// 외부 변경 -> 깨끗한 버퍼는 다시 불러옴
+	st.Save([]byte("external"))
+	changed, err := sp.FileChangedOnDisk()
+	if err != nil || !changed {
+		t.Fatalf("외부 변경 감지 실패: %v %v", changed, err)
+	}
+	if err := sp.ReloadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sp.doc.data.head.PieceTable.String(); got != "external" {
+		t.Fatalf("다시 불러온 첫 라인 = %q", got)
+	}
+}
+
+func TestZoomKeepsCursorLineInView(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	st := storage.NewMemoryStorage([]byte(strings.Join(lines, "\n")))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	if n := sp.LineCount(); n != 100 {
+		t.Fatalf("화면보다 긴 파일이 잘림: %d 라인", n)
+	}
+
+	for range 60 {
+		sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyDown}})
+	}
+	if got := sp.cursorLineIndex(); got != 60 {
+		t.Fatalf("커서 라인 = %d", got)
+	}
+
+	sp.SetScale(2)
+	if sp.LineHeight != 32 || len(sp.cursor.currentLineBuffer.data) != 32*800 {
+		t.Fatalf("배율 2에서 라인 높이 = %d", sp.LineHeight)
+	}
+	if got := sp.cursorLineIndex(); got != 60 {
+		t.Fatalf("확대 후 커서 라인 = %d", got)
+	}
+	top, visible := sp.ViewTop(), sp.visibleLineCount()
+	if top > 60 || 60 >= top+visible {
+		t.Fatalf("커서 라인이 화면 밖: top=%d visible=%d", top, visible)
+	}
+	if n := len(sp.FlushLineBuffer()); n != visible {
+		t.Fatalf("화면에 보낸 라인 수 = %d, want %d", n, visible)
+	}
+
+	sp.SetScale(MaxScale + 1)
+	if sp.Scale() != MaxScale {
+		t.Fatalf("배율 상한 초과: %d", sp.Scale())
+	}
+}
+
+func TestGutterOffsetsTextAndClicks(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("ab\n\tc"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.SetGutterMode(GutterAbsolute)
+
+	// 문서 라인 수의 자리수(최소 2자리) + 여백 1칸
+	if n := sp.LineCount(); n != 2 {
+		t.Fatalf("라인 수 = %d", n)
+	}
+	digits := max(len(strconv.Itoa(sp.LineCount())), gutterMinDigits)
+	gw := (digits + gutterPadding) * glp.GlyphWidth
+	if sp.gutterWidth != gw {
+		t.Fatalf("거터 폭 = %d, want %d", sp.gutterWidth, gw)
+	}
+	if col, _ := sp.cursor.mapInset2pixColRow(sp); col != gw {
+		t.Fatalf("커서 x = %d, want %d", col, gw)
+	}
+
+	// 둘째 줄 탭 뒤의 'c'를 클릭
+	sp.ProcessCommand(commander.Command{Code: commander.CmdClick, Input: commander.ClickInput{
+		Height: sp.LineHeight + 1, Width: gw + 4*glp.GlyphWidth + 1,
+	}})
+	if line := sp.cursorLineIndex(); line != 1 || sp.cursor.currentCharInset != 1 {
+		t.Fatalf("클릭 위치 = (%d, %d)", line, sp.cursor.currentCharInset)
+	}
+
+	// 거터를 누르면 줄 맨 앞
+	sp.ProcessCommand(commander.Command{Code: commander.CmdClick, Input: commander.ClickInput{Height: 1, Width: 2}})
+	if line := sp.cursorLineIndex(); line != 0 || sp.cursor.currentCharInset != 0 {
+		t.Fatalf("거터 클릭 위치 = (%d, %d)", line, sp.cursor.currentCharInset)
+	}
+
+	// 100라인이 되면 3자리로 늘어남
+	if err := sp.Document().Insert(0, 0, strings.Repeat("\n", 98)); err != nil {
+		t.Fatal(err)
+	}
+	if sp.LineCount() != 100 || sp.gutterWidth != (3+gutterPadding)*glp.GlyphWidth {
+		t.Fatalf("100라인 거터 폭 = %d", sp.gutterWidth)
+	}
+
+	sp.SetGutterMode(GutterOff)
+	if sp.gutterWidth != 0 {
+		t.Fatalf("거터를 끈 뒤 폭 = %d", sp.gutterWidth)
+	}
+}
+
+func TestStatusAndCRLFRoundTrip(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("one\r\ntwo"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	// 아래로 이동해도 칸은 유지 (라인 처음)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyDown}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'X'}})
+
+	status := sp.Status()
+	if status.Line != 2 || status.Column != 2 || !status.Dirty || status.EOL != "CRLF" || status.Encoding != "utf-8" {
+		t.Fatalf("상태 = %+v", status)
+	}
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSave})
+	data, _ := st.Load()
+	if string(data) != "one\r\nXtwo" {
+		t.Fatalf("CRLF가 유지되지 않음: %q", data)
+	}
+	if msg := sp.Status().Message; !strings.HasPrefix(msg, "Saved") {
+		t.Fatalf("저장 메시지 = %q", msg)
+	}
+	if n := len(sp.RenderStatusBar(sp.Status())); n != 800*sp.StatusBarHeight() {
+		t.Fatalf("상태 표시줄 픽셀 수 = %d", n)
+	}
+}
+
+func TestStatusErrorMessagesAreASCII(t *testing.T) {
+	st := storage.NewReadOnlyStorage(storage.NewMemoryStorage([]byte("한글")))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'X'}})
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSave})
+	if msg := sp.Status().Message; msg != "Save failed: file is read-only" {
+		t.Fatalf("저장 실패 메시지 = %q", msg)
+	}
+	sp.ProcessCommand(commander.Command{Code: commander.CmdReopenWithEncoding, Input: commander.EncodingInput{Name: "cp949"}})
+	if msg := sp.Status().Message; msg != "cp949 failed: unsaved changes, save first" {
+		t.Fatalf("다시 열기 실패 메시지 = %q", msg)
+	}
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSaveWithEncoding, Input: commander.EncodingInput{Name: "euc-kr"}})
+	if msg := sp.Status().Message; !isASCII([]byte(msg)) {
+		t.Fatalf("ASCII가 아닌 메시지 = %q", msg)
+	}
+
+	if got := StatusError(errors.New("알 수 없는 오류")); got != "see log for details" {
+		t.Fatalf("한국어 에러 = %q", got)
+	}
+	if got := StatusError(errors.New("bad pattern")); got != "bad pattern" {
+		t.Fatalf("ASCII 에러 = %q", got)
+	}
+}
+
+func TestGotoAndSubstituteLine(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("a\nkey=value key=other"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdGoto, Input: commander.LineInput{Line: 2}})
+	if line := sp.cursorLineIndex(); line != 1 {
+		t.Fatalf("goto 2 -> 라인 %d", line)
+	}
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSubstitute, Input: commander.SubstituteInput{
+		Pattern: `(\w+)=(\w+)`, Replacement: "${2}:$1", Global: true,
+	}})
+	if got := sp.documentLines()[1]; got != "value:key other:key" {
+		t.Fatalf("치환 결과 = %q", got)
+	}
+	if !sp.IsDirty() {
+		t.Fatal("치환 후 dirty가 아님")
+	}
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdGoto, Input: commander.LineInput{Line: 1000}})
+	if line := sp.cursorLineIndex(); line != sp.LineCount()-1 {
+		t.Fatalf("범위 밖 goto -> 라인 %d", line)
+	}
+}
+
+func TestIncrementalSearch(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("foo bar\nbar\nbaz bar"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.GotoLine(2)
+
+	sp.StartSearch()
+	sp.UpdateSearch("ba")
+	if cur, total := sp.SearchStatus(); cur != 2 || total != 4 {
+		t.Fatalf("\"ba\" 매치 = %d/%d", cur, total)
+	}
+	sp.UpdateSearch("bar")
+	if cur, total := sp.SearchStatus(); cur != 2 || total != 3 {
+		t.Fatalf("\"bar\" 매치 = %d/%d", cur, total)
+	}
+	// 매치 글자 칸의 배경이 강조색
+	first := sp.doc.data.head.LineBuffer.data[4*glp.GlyphWidth]
+	if first != defaultMatchColor {
+		t.Fatalf("매치 배경색 = %#x", first)
+	}
+
+	if _, wrapped := sp.FindNext(true); wrapped || sp.cursorLineIndex() != 2 || sp.cursor.currentCharInset != 4 {
+		t.Fatalf("다음 매치 = (%d, %d) wrapped=%v", sp.cursorLineIndex(), sp.cursor.currentCharInset, wrapped)
+	}
+	if _, wrapped := sp.FindNext(true); !wrapped || sp.cursorLineIndex() != 0 {
+		t.Fatalf("끝에서 처음으로 넘어가지 않음: 라인 %d wrapped=%v", sp.cursorLineIndex(), wrapped)
+	}
+	if _, wrapped := sp.FindNext(false); !wrapped || sp.cursorLineIndex() != 2 {
+		t.Fatalf("처음에서 끝으로 넘어가지 않음: 라인 %d", sp.cursorLineIndex())
+	}
+
+	// 취소하면 시작 위치로, 강조 해제
+	sp.EndSearch(false)
+	if sp.cursorLineIndex() != 1 || sp.doc.data.head.LineBuffer.data[4*glp.GlyphWidth] == defaultMatchColor {
+		t.Fatalf("검색 취소 후 라인 %d", sp.cursorLineIndex())
+	}
+}
+
+func TestReplaceAcrossLinesAndUndo(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("ya\nb\nxa\nb\nfoo foo\nfoo foo"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	original := strings.Join(sp.documentLines(), "\n")
+
+	// %s: 라인 경계를 넘는 매치 + 캡처 그룹
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSubstitute, Input: commander.SubstituteInput{
+		Pattern: `(\w)a\nb`, Replacement: "<$1>", Global: true, Range: commander.RangeDocument,
+	}})
+	if got := strings.Join(sp.documentLines(), "\n"); got != "<y>\n<x>\nfoo foo\nfoo foo" {
+		t.Fatalf("전체 치환 결과 = %q", got)
+	}
+
+	// 되돌리기 한 번에 치환 전체가 취소됨
+	sp.ProcessCommand(commander.Command{Code: commander.CmdUndo})
+	if got := strings.Join(sp.documentLines(), "\n"); got != original {
+		t.Fatalf("되돌리기 결과 = %q", got)
+	}
+
+	// 선택 영역: 5번째 라인 시작 ~ 6번째 라인 "foo" 뒤
+	sp.GotoLine(5)
+	sp.SetMark()
+	sp.GotoLine(6)
+	sp.moveCursorTo(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer), 3)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSubstitute, Input: commander.SubstituteInput{
+		Pattern: "foo", Replacement: "bar", Global: true, Range: commander.RangeSelection,
+	}})
+	lines := sp.documentLines()
+	if lines[4] != "bar bar" || lines[5] != "bar foo" {
+		t.Fatalf("선택 영역 치환 결과 = %q", lines[4:])
+	}
+
+	// 확인 모드: 첫 번째는 건너뛰고 두 번째만 바꿈
+	rs, err := sp.BeginReplace(commander.SubstituteInput{Pattern: "bar", Replacement: "baz", Global: true, Confirm: true, Range: commander.RangeDocument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, total := rs.Progress(); total != 3 {
+		t.Fatalf("확인 모드 매치 수 = %d", total)
+	}
+	rs.Answer(false)
+	rs.Answer(true)
+	if n := rs.Finish(); n != 1 {
+		t.Fatalf("확인 모드 치환 수 = %d", n)
+	}
+	if lines := sp.documentLines(); lines[4] != "bar baz" || lines[5] != "bar foo" {
+		t.Fatalf("확인 모드 결과 = %q", lines[4:])
+	}
+}
+
+func TestReplaceSelectionBoundaries(t *testing.T) {
+	st := storage.NewMemoryStorage([]byte("xfoo foo\nabc"))
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+
+	// 선택 시작(1칸)은 단어 중간이라 \b, ^가 맞으면 안 됨
+	sp.moveCursorTo(sp.doc.data.head, 1)
+	sp.SetMark()
+	sp.moveCursorTo(sp.doc.data.head, 8)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSubstitute, Input: commander.SubstituteInput{
+		Pattern: `\bfoo|^foo`, Replacement: "bar", Global: true, Range: commander.RangeSelection,
+	}})
+	if got := sp.documentLines()[0]; got != "xfoo bar" {
+		t.Fatalf("선택 영역 경계 치환 = %q", got)
+	}
+
+	// 선택 끝(2칸)은 라인 끝이 아니므로 $가 맞으면 안 됨
+	sp.GotoLine(2)
+	sp.SetMark()
+	sp.moveCursorTo(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer), 2)
+	n, err := sp.Substitute(commander.SubstituteInput{Pattern: `b$`, Replacement: "B", Range: commander.RangeSelection})
+	if err != nil || n != 0 {
+		t.Fatalf("선택 끝의 $ 매치 %d개 (err %v)", n, err)
+	}
+}
+
+func TestUndoRecordsChangedNodes(t *testing.T) {
+	lines := make([]string, 500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	original := strings.Join(lines, "\n")
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte(original)), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+
+	sp.GotoLine(250)
+	for _, r := range "ab" {
+		sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: r}})
+	}
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}})
+	// 기록에는 문서 전체가 아니라 커서 주변 노드만 있음
+	for _, entry := range sp.undoStack {
+		if len(entry.nodes) > 10 {
+			t.Fatalf("되돌리기 기록의 노드 수 = %d", len(entry.nodes))
+		}
+	}
+
+	// 여러 라인으로 늘어나는 치환도 바뀐 라인만 기록
+	n, err := sp.Substitute(commander.SubstituteInput{Pattern: `line 10$`, Replacement: "ten\nTEN", Range: commander.RangeDocument})
+	if err != nil || n != 1 {
+		t.Fatalf("치환 %d개 (err %v)", n, err)
+	}
+	if entry := sp.undoStack[len(sp.undoStack)-1]; len(entry.nodes) > 10 {
+		t.Fatalf("치환 기록의 노드 수 = %d", len(entry.nodes))
+	}
+	got := sp.documentLines()
+	if got[10] != "ten" || got[11] != "TEN" || got[250] != "ab" || got[251] != "line 249" {
+		t.Fatalf("편집 결과 = %q, %q", got[10:12], got[250:252])
+	}
+
+	// 화면 없는 문서 API로 고친 것도 되돌림
+	if err := sp.Document().Insert(0, 0, "head\n"); err != nil {
+		t.Fatal(err)
+	}
+	sp.Undo()
+	sp.Undo()
+	if got := sp.documentLines(); got[0] != "line 0" || got[10] != "line 10" || got[11] != "line 11" {
+		t.Fatalf("되돌리기 결과 = %q", got[:12])
+	}
+	sp.Redo()
+	if got := sp.documentLines(); got[10] != "ten" || got[11] != "TEN" {
+		t.Fatalf("다시 실행 결과 = %q", got[10:12])
+	}
+	sp.Undo()
+	for sp.Undo() {
+	}
+	if got := strings.Join(sp.documentLines(), "\n"); got != original {
+		t.Fatal("모두 되돌린 결과가 원본과 다름")
+	}
+	sp.Redo()
+	sp.Redo()
+	if got := sp.documentLines(); got[249] != "ab" || got[250] != "line 249" {
+		t.Fatalf("입력 다시 실행 = %q", got[249:251])
+	}
+}
+
+func TestUndoGroupsTyping(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("x")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	for _, r := range "abc" {
+		sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: r}})
+	}
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'd'}})
+
+	if got := strings.Join(sp.documentLines(), "\n"); got != "abc\ndx" {
+		t.Fatalf("입력 결과 = %q", got)
+	}
+	sp.Undo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "abc\nx" {
+		t.Fatalf("첫 되돌리기 = %q", got)
+	}
+	// Enter는 따로 한 묶음
+	sp.Undo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "abcx" {
+		t.Fatalf("줄바꿈 되돌리기 = %q", got)
+	}
+	sp.Undo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "x" {
+		t.Fatalf("입력 묶음 되돌리기 = %q", got)
+	}
+	if sp.Undo() {
+		t.Fatal("더 되돌릴 게 없어야 함")
+	}
+	sp.Redo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "abcx" {
+		t.Fatalf("다시 실행 = %q", got)
+	}
+}
+
+func TestInvariantCheckReportsBadOps(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("ab\nlonger line")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	SetDebugChecks(true)
+	defer SetDebugChecks(false)
+
+	// 아래로 갔다가 위로: 짧은 라인에서는 라인 끝으로
+	sp.GotoLine(2)
+	sp.moveCursorTo(sp.doc.data.head.next, 10)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyUp}})
+	if sp.cursorLineIndex() != 0 || sp.cursor.currentCharInset != 2 {
+		t.Fatalf("위로 이동 후 커서 = (%d, %d)", sp.cursorLineIndex(), sp.cursor.currentCharInset)
+	}
+
+	// 가드 없이 첫 라인에서 위로 가는 op: 패닉 대신 기록과 함께 에러, 앞의 op도 롤백
+	sp.GotoLine(1)
+	ops := NewOpSequences()
+	ops.Append(NewOpNodeText(OpInsertRune, 'x'))
+	ops.Append(NewOpNodeCursor(OpRightCursor))
+	ops.Append(NewOpNodeCursor(OpUpCursor))
+	err := ops.ExecuteAll(sp)
+	invErr, ok := err.(*InvariantError)
+	if !ok || !invErr.RolledBack {
+		t.Fatalf("롤백된 InvariantError가 아님: %v", err)
+	}
+	if len(invErr.Trace) != 3 || invErr.Trace[2] != "CURSOR up" {
+		t.Fatalf("op 기록 = %q", invErr.Trace)
+	}
+	if got := sp.doc.data.head.PieceTable.String(); got != "ab" || sp.cursor.currentCharInset != 0 {
+		t.Fatalf("롤백 후 라인 = %q, 인셋 %d", got, sp.cursor.currentCharInset)
+	}
+
+	// 라인을 자른 뒤 인셋을 라인 끝 밖으로: 새 노드가 빠지고 원래 두 라인으로
+	ops = NewOpSequences()
+	ops.Append(NewOpNodeCursor(OpRightCursor))
+	ops.Append(NewOpNodeGroup(OpSliceNodeAtGroup, sp.doc.data.head))
+	ops.Append(NewOpNodeSync(OpNodeSlicedSync, sp.doc.data.head))
+	ops.Append(NewOpNodeCursor(OpRightEndCursor))
+	ops.Append(NewOpNodeCursor(OpRightCursor))
+	if err := ops.ExecuteAll(sp); err == nil || !strings.Contains(err.Error(), "커서 인셋") {
+		t.Fatalf("인셋 위반이 보고되지 않음: %v", err)
+	}
+	if got := strings.Join(sp.documentLines(), "\n"); got != "ab\nlonger line" {
+		t.Fatalf("롤백 후 문서 = %q", got)
+	}
+	if v := sp.checkInvariants(); len(v) != 0 {
+		t.Fatalf("롤백 후에도 위반: %q", v)
+	}
+}
+
+// upperLineOp: 외부에서 추가하는 op 예시 (커서 라인을 대문자로)
+type upperLineOp struct{ kind OpKind }
+
+func (u upperLineOp) Info() OpInfo { return OpInfo{Kind: u.kind} }
+func (u upperLineOp) Execute(sp *SyncProtocol) {
+	node := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	node.PieceTable = NewPieceTable(strings.ToUpper(node.PieceTable.String()))
+	sp.syncNode(node)
+}
+
+func TestEnterOnLastLine(t *testing.T) {
+	// 화면을 빈 라인으로 채우지 않으므로 마지막 라인에서도 Enter가 새 라인을 만들고 내려가야 함
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("ab\ncd")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.GotoLine(2)
+	sp.moveCursorTo(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer), 2)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'e'}})
+	if got := sp.doc.Text(); got != "ab\ncd\ne" || sp.cursorLineIndex() != 2 {
+		t.Fatalf("끝 Enter = %q, 커서 라인 %d", got, sp.cursorLineIndex())
+	}
+	if !sp.Undo() || !sp.Undo() || sp.doc.Text() != "ab\ncd" {
+		t.Fatalf("되돌리기 = %q", sp.doc.Text())
+	}
+}
+
+func TestRegisterOpBuilder(t *testing.T) {
+	const cmdUpper = commander.CommandCode(200)
+	kind := RegisterOpKind("Case", "upper")
+	RegisterOpBuilder(cmdUpper, OpBuilder{Edit: true, Build: func(sp *SyncProtocol, cmd commander.Command) (*OpSequences, bool) {
+		ops := NewOpSequences()
+		ops.Append(upperLineOp{kind}, NewOpNodeCursor(OpRightEndCursor))
+		return ops, true
+	}})
+	defer UnregisterOpBuilder(cmdUpper)
+
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("abc\ndef")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.ProcessCommand(commander.Command{Code: cmdUpper})
+	if got := strings.Join(sp.documentLines(), "\n"); got != "ABC\ndef" || sp.cursor.currentCharInset != 3 || !sp.IsDirty() {
+		t.Fatalf("등록한 op 결과 = %q, 인셋 %d", got, sp.cursor.currentCharInset)
+	}
+	if name := (upperLineOp{kind}).Info().String(); name != "CASE upper" {
+		t.Fatalf("op 이름 = %q", name)
+	}
+	sp.Undo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "abc\ndef" {
+		t.Fatalf("되돌리기 결과 = %q", got)
+	}
+}
+
+func TestOptimizeCoalescesTyping(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("xy")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.moveCursorTo(sp.doc.data.head, 1)
+
+	var cmds []commander.Command
+	batch := NewOpSequences()
+	for _, r := range "abc" {
+		cmd := commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: r}}
+		cmds = append(cmds, cmd)
+		ops, _, _ := sp.buildOpSequences(cmd)
+		batch.Append(ops.ops...)
+	}
+	var names []string
+	optimize(batch).ForEach(func(op Op) { names = append(names, op.Info().String()) })
+	if got := strings.Join(names, ", "); got != `TEXT type "abc", SYNC modified` {
+		t.Fatalf("최적화 결과 = %s", got)
+	}
+
+	// 홀드만 있는 시퀀스는 비워짐
+	hold := NewOpSequences()
+	hold.Append(NewOpNodeGroup(OpHoldAllGroup, sp.doc.data.head), NewOpNodeText(OpHoldRune, ' '),
+		NewOpNodeSync(OpNodeHoldSync, sp.doc.data.head), NewOpNodeCursor(OpHoldCursor))
+	if n := optimize(hold).Len(); n != 0 {
+		t.Fatalf("홀드 op가 %d개 남음", n)
+	}
+
+	cmds = append(cmds, commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}},
+		commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'd'}})
+	sp.ProcessCommands(cmds)
+	if got := strings.Join(sp.documentLines(), "\n"); got != "xabc\ndy" || sp.cursor.currentCharInset != 1 {
+		t.Fatalf("묶음 입력 결과 = %q, 인셋 %d", got, sp.cursor.currentCharInset)
+	}
+	sp.Undo()
+	sp.Undo()
+	sp.Undo()
+	if got := strings.Join(sp.documentLines(), "\n"); got != "xy" {
+		t.Fatalf("되돌리기 결과 = %q", got)
+	}
+}
+
+func TestOpAssemblyRoundTrip(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("one\ntwo\nthree")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.GotoLine(3)
+	sp.moveCursorTo(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer), 2)
+
+	enter := commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}}
+	ops, _, _ := sp.buildOpSequences(enter)
+	const want = "GROUP slice @L3; TEXT hold; SYNC sliced @L3; CURSOR downLeftStart"
+	if got := ops.Format(sp); got != want {
+		t.Fatalf("프로그램 = %s", got)
+	}
+
+	src := "# 주석\nTEXT insert ';'; TEXT type \"a b\\n\"\nCURSOR right; SYNC modified @L1"
+	parsed, err := ParseOps(sp, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const formatted = `TEXT insert ';'; TEXT type "a b\n"; CURSOR right; SYNC modified @L1`
+	if got := parsed.Format(sp); got != formatted {
+		t.Fatalf("파싱 후 = %s", got)
+	}
+	again, err := ParseOps(sp, formatted)
+	if err != nil || again.Format(sp) != formatted {
+		t.Fatalf("다시 파싱 = %v, %v", again, err)
+	}
+
+	for _, bad := range []string{"GROUP slice", "CURSOR sideways", "SYNC modified @L99", "TEXT insert 'ab'", "NOPE x"} {
+		if _, err := ParseOps(sp, bad); err == nil {
+			t.Fatalf("%q 가 오류 없이 파싱됨", bad)
+		}
+	}
+
+	// 실행 기록
+	var trace strings.Builder
+	SetOpTrace(&trace)
+	defer SetOpTrace(nil)
+	sp.ProcessCommand(enter)
+	if got := trace.String(); got != "GROUP slice @L3; SYNC sliced @L3; CURSOR downLeftStart\n" {
+		t.Fatalf("실행 기록 = %q", got)
+	}
+}
+
+// pendingProbe: 실행 중에 모아둔 이벤트 수를 기록하는 op
+type pendingProbe struct{ pending *int }
+
+func (p pendingProbe) Info() OpInfo { return OpInfo{Kind: OpKindCursor, Code: int(OpHoldCursor)} }
+func (p pendingProbe) Execute(sp *SyncProtocol) {
+	*p.pending = len(sp.doc.changes.pending)
+}
+
+func TestChangesWithoutObservers(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("ab\ncd")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.StartSearch()
+	sp.UpdateSearch("x")
+
+	// 구독자가 없으면 이벤트를 모으지 않지만 dirty와 검색 갱신은 표시됨
+	pending := -1
+	ops := NewOpSequences()
+	ops.Append(NewOpNodeText(OpInsertRune, 'x'), NewOpNodeGroup(OpSliceNodeAtGroup, sp.doc.data.head), pendingProbe{&pending})
+	if err := ops.ExecuteAll(sp); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 || !sp.IsDirty() || !sp.search.stale {
+		t.Fatalf("모아둔 이벤트 %d개, dirty %v, 검색 갱신 %v", pending, sp.IsDirty(), sp.search.stale)
+	}
+
+	// 구독하면 내용이 채워진 이벤트를 받음
+	var got []ChangeEvent
+	sp.Subscribe(func(ev ChangeEvent) { got = append(got, ev) })
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'y'}})
+	if len(got) != 1 || got[0].Kind != NodeModified || len(got[0].After) != 1 {
+		t.Fatalf("이벤트 = %+v", got)
+	}
+}
+
+func TestChangeEvents(t *testing.T) {
+	sp := LoadSyncProtocol(storage.NewMemoryStorage([]byte("ab\ncd")), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	var got []string
+	unsubscribe := sp.Subscribe(func(ev ChangeEvent) {
+		got = append(got, fmt.Sprintf("%d@%d %q->%q", ev.Kind, ev.Line, ev.Before, ev.After))
+	})
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyRight}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: commander.KeyEnter1}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdDelete, Input: commander.CharInput{Char: commander.KeyBackSpace}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'x'}})
+	want := []string{
+		fmt.Sprintf("%d@0 [\"ab\"]->[\"a\" \"b\"]", NodeSliced),
+		fmt.Sprintf("%d@0 [\"a\" \"b\"]->[\"ab\"]", NodeMerged),
+		fmt.Sprintf("%d@0 [\"ab\"]->[\"axb\"]", NodeModified),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || !sp.IsDirty() {
+		t.Fatalf("이벤트 = %q, dirty %v", got, sp.IsDirty())
+	}
+
+	// 롤백된 실행은 알리지 않음
+	got = nil
+	ops := NewOpSequences()
+	ops.Append(NewOpNodeText(OpInsertRune, 'y'), NewOpNodeCursor(OpRightEndCursor), NewOpNodeCursor(OpRightCursor))
+	if err := ops.ExecuteAll(sp); err == nil || len(got) != 0 {
+		t.Fatalf("롤백 후 이벤트 = %q (err %v)", got, err)
+	}
+
+	sp.Undo()
+	if len(got) != 1 || got[0][:1] != fmt.Sprint(DocumentReplaced) {
+		t.Fatalf("되돌리기 이벤트 = %q", got)
+	}
+	unsubscribe()
+	sp.Undo()
+	if len(got) != 1 {
+		t.Fatalf("구독 해제 후 이벤트 = %q", got)
+	}
+}
+
+func TestDocumentHeadless(t *testing.T) {
+	doc := NewDocument("hello\nworld")
+	if err := doc.Insert(0, 5, ",\nbig\nnew"); err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Text(); got != "hello,\nbig\nnew\nworld" || doc.LineCount() != 4 {
+		t.Fatalf("삽입 결과 = %q", got)
+	}
+	if err := doc.Delete(Range{Start: Position{1, 1}, End: Position{3, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := doc.Line(1); line != "brld" || doc.LineCount() != 2 {
+		t.Fatalf("삭제 결과 = %q", doc.Text())
+	}
+	off, err := doc.Offset(Position{1, 2})
+	if err != nil || off != 9 {
+		t.Fatalf("오프셋 = %d, %v", off, err)
+	}
+	if pos, _ := doc.PositionAt(off); pos != (Position{1, 2}) {
+		t.Fatalf("위치 = %+v", pos)
+	}
+	if err := doc.Insert(5, 0, "x"); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("범위 밖 삽입 에러 = %v", err)
+	}
+	doc.data.ForEach(func(sn *SyncNode) {
+		if sn.LineBuffer != nil {
+			t.Fatal("헤드리스 문서에 라인버퍼가 생김")
+		}
+	})
+
+	// 뷰를 붙이면 문서 편집이 화면과 dirty에 반영됨
+	sp, err := NewView(doc, storage.NewMemoryStorage(nil), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.LineCount() != 2 || doc.Text() != "hello,\nbrld" {
+		t.Fatalf("뷰를 붙인 뒤 문서 = %q (%d 라인)", doc.Text(), doc.LineCount())
+	}
+	if n := len(sp.FlushLineBuffer()); n != 2 {
+		t.Fatalf("화면에 보낸 라인 수 = %d", n)
+	}
+	if err := doc.Delete(Range{Start: Position{0, 5}, End: Position{1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sp.documentLines(), "\n"); got != "hellobrld" || !sp.IsDirty() {
+		t.Fatalf("뷰의 문서 = %q, dirty %v", got, sp.IsDirty())
+	}
+	if sp.doc.data.head.LineBuffer == nil || sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer) == nil {
+		t.Fatal("뷰의 라인버퍼/커서가 문서를 따라가지 않음")
+	}
+}
+
+func TestPieceTreeMatchesStringModel(t *testing.T) {
+	pt := NewPieceTable("héllo wörld")
+	model := []rune(pt.String())
+	for i := range 2000 {
+		switch pos := (i * 7919) % (len(model) + 1); i % 3 {
+		case 0, 1:
+			text := string(rune('a' + i%26))
+			if i%5 == 0 {
+				text = "가나" // 추가 버퍼도 ASCII에서 멀티바이트로 넘어가게
+			}
+			pt.Insert(pos, text)
+			model = append(model[:pos], append([]rune(text), model[pos:]...)...)
+		case 2:
+			if pos == 0 {
+				continue
+			}
+			n := min(pos, 1+i%4)
+			pt.Delete(pos, n)
+			model = append(model[:pos-n], model[pos:]...)
+		}
+		if pt.Length() != len(model) {
+			t.Fatalf("%d번째 연산 뒤 길이 %d, 기대 %d", i, pt.Length(), len(model))
+		}
+	}
+	if pt.String() != string(model) {
+		t.Fatalf("내용이 다름:\n%q\n%q", pt.String(), string(model))
+	}
+
+	// 나눠도, 사본을 고쳐도 원본 트리는 그대로
+	before := pt.String()
+	copied := pt.clone()
+	copied.Insert(3, "XYZ")
+	front, back := pt.SlicePieceTable(len(model) / 2)
+	if front.String()+back.String() != before || pt.String() != before {
+		t.Fatalf("분할 뒤 원본 = %q", pt.String())
+	}
+}
+
+func TestLoadMappedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	if err := os.WriteFile(path, []byte("첫 줄\r\nsecond\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sp := LoadSyncProtocol(storage.NewMappedFileStorage(path), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyRight}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: '!'}})
+	if line, _ := sp.Document().Line(0); line != "첫! 줄" {
+		t.Fatalf("매핑한 라인 편집 = %q", line)
+	}
+	if err := sp.SaveToFile(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "첫! 줄\r\nsecond" {
+		t.Fatalf("저장 내용 = %q", data)
+	}
+}
+
+// countingMapper: 매핑 해제 횟수를 세는 저장소
+type countingMapper struct {
+	*storage.MappedFileStorage
+	unmapped int
+}
+
+func (cm *countingMapper) Map() ([]byte, func() error, error) {
+	data, unmap, err := cm.MappedFileStorage.Map()
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error {
+		cm.unmapped++
+		return unmap()
+	}, nil
+}
+
+func TestMappingReleasedOnReloadAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	st := &countingMapper{MappedFileStorage: storage.NewMappedFileStorage(path)}
+	sp := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	if err := os.WriteFile(path, []byte("three"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.ReloadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+	if st.unmapped != 1 || sp.Document().Text() != "three" {
+		t.Fatalf("다시 불러온 뒤 해제 %d번, 문서 %q", st.unmapped, sp.Document().Text())
+	}
+	sp.Close()
+	sp.Close()
+	if st.unmapped != 1 {
+		t.Fatalf("다시 불러온 뒤 닫기에서 해제 %d번", st.unmapped)
+	}
+
+	other := LoadSyncProtocol(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	other.Close()
+	other.Close()
+	if st.unmapped != 2 {
+		t.Fatalf("닫은 뒤 해제 %d번", st.unmapped)
+	}
+}
+
+func TestMappedFileChangedInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sp := LoadSyncProtocol(storage.NewMappedFileStorage(path), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	if changed, err := sp.FileChangedOnDisk(); err != nil || changed {
+		t.Fatalf("연 직후 변경 = %v, %v", changed, err)
+	}
+
+	// 다른 프로그램이 같은 길이로 제자리에서 덮어씀 (매핑된 내용도 같이 바뀜)
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("ONE"), 0); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if changed, err := sp.FileChangedOnDisk(); err != nil || !changed {
+		t.Fatalf("제자리 변경 = %v, %v", changed, err)
+	}
+
+	// 닫은 뒤에도 해제된 매핑을 읽지 않음
+	sp.Close()
+	if changed, err := sp.FileChangedOnDisk(); err != nil || !changed {
+		t.Fatalf("닫은 뒤 변경 = %v, %v", changed, err)
+	}
+}
+
+func TestPieceTableSharedBuffers(t *testing.T) {
+	pt := NewPieceTable("abc")
+	pt.Insert(3, "def")
+	front, back := pt.SlicePieceTable(4)
+	// 두 반쪽이 같은 추가 버퍼에 덧붙여도 서로의 글자를 덮어쓰지 않음
+	front.Insert(4, "X")
+	back.Insert(0, "Y")
+	if front.String() != "abcdX" || back.String() != "Yef" || pt.String() != "abcdef" {
+		t.Fatalf("분할 후 = %q, %q, 원본 %q", front.String(), back.String(), pt.String())
+	}
+	merged := front.Concat(back)
+	if merged.String() != "abcdXYef" || merged.addBuffer != pt.addBuffer {
+		t.Fatalf("같은 버퍼 잇기 = %q", merged.String())
+	}
+	if other := merged.Concat(NewPieceTable("!")); other.String() != "abcdXYef!" {
+		t.Fatalf("다른 버퍼 잇기 = %q", other.String())
+	}
+
+	// 입력하고 지우기를 반복하면 추가 버퍼가 정리됨
+	line := NewPieceTable("keep")
+	for range 3000 {
+		line.Insert(4, "tmp")
+		line.Delete(7, 3)
+	}
+	if line.String() != "keep" || line.addBuffer.runes > compactMinRunes+3 {
+		t.Fatalf("정리 후 = %q, 추가 버퍼 %d글자", line.String(), line.addBuffer.runes)
+	}
+}
+
+func TestSplitLinesKeepSharedBuffer(t *testing.T) {
+	// 붙여넣은 긴 텍스트를 라인마다 나눈 뒤 각 라인을 고쳐도 같은 추가 버퍼를 계속 나눠 씀
+	const width, count = 40, 200
+	pasted := NewPieceTable("")
+	pasted.Insert(0, strings.Repeat(strings.Repeat("x", width-1)+";", count))
+	var lines []*PieceTable
+	rest := pasted
+	for range count - 1 {
+		front, back := rest.SlicePieceTable(width)
+		lines = append(lines, front)
+		rest = back
+	}
+	lines = append(lines, rest)
+
+	for i, line := range lines {
+		line.Insert(1, "ab")
+		line.Delete(2, 1)
+		if line.addBuffer != pasted.addBuffer {
+			t.Fatalf("%d번째 라인이 버퍼를 따로 만듦", i)
+		}
+		if want := "xb" + strings.Repeat("x", width-2) + ";"; line.String() != want {
+			t.Fatalf("%d번째 라인 = %q", i, line.String())
+		}
+	}
+	if live := pasted.addBuffer.live; live != count*(width+1) {
+		t.Fatalf("살아 있는 글자 수 = %d", live)
+	}
+}
+
+func TestPieceStoreMatchesLineStore(t *testing.T) {
+	const text = "첫 줄\nsecond line\n\nlast"
+	docs := []*Document{NewDocument(text), NewDocumentOn(NewPieceStore(text))}
+	events := make([][]string, len(docs))
+	for i, doc := range docs {
+		doc.Subscribe(func(ev ChangeEvent) {
+			events[i] = append(events[i], fmt.Sprintf("%d@%d %q->%q", ev.Kind, ev.Line, ev.Before, ev.After))
+		})
+	}
+	for step := range 200 {
+		for _, doc := range docs {
+			line := step % doc.LineCount()
+			length := len([]rune(mustLine(t, doc, line)))
+			switch step % 4 {
+			case 0:
+				doc.Insert(line, length/2, "a\nb")
+			case 1:
+				doc.Insert(line, length, "xyz")
+			case 2:
+				end := Position{min(line+1, doc.LineCount()-1), 0}
+				doc.Delete(Range{Start: Position{line, length / 2}, End: end})
+			case 3:
+				doc.Insert(0, 0, "가")
+			}
+		}
+		if a, b := docs[0].Text(), docs[1].Text(); a != b {
+			t.Fatalf("%d번째 편집 뒤 다름:\n%q\n%q", step, a, b)
+		}
+	}
+	if strings.Join(events[0], "\n") != strings.Join(events[1], "\n") {
+		t.Fatal("두 저장 방식의 변경 이벤트가 다름")
+	}
+	for offset := 0; offset < len([]rune(docs[0].Text())); offset += 37 {
+		pa, _ := docs[0].PositionAt(offset)
+		pb, _ := docs[1].PositionAt(offset)
+		back, _ := docs[1].Offset(pb)
+		if pa != pb || back != offset {
+			t.Fatalf("오프셋 %d: %+v / %+v (되돌린 오프셋 %d)", offset, pa, pb, back)
+		}
+	}
+}
+
+func mustLine(t *testing.T, doc *Document, i int) string {
+	line, err := doc.Line(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
+
+// BenchmarkDocumentBackends: 라인별 저장과 문서 전체 PieceTable 비교
+// (긴 문서 가운데에서 입력, 줄바꿈, 백스페이스로 라인 합치기, 여러 줄 붙여넣기)
+func TestNewViewRejectsPieceStore(t *testing.T) {
+	store := NewPieceStore("one\ntwo")
+	doc := NewDocumentOn(store)
+	if _, err := NewView(doc, storage.NewMemoryStorage(nil), 800, 600, 0xFF000000, 0xFFFFFFFF, 16); !errors.Is(err, ErrNotLineStore) {
+		t.Fatalf("NewView 오류 = %v", err)
+	}
+	if doc.store != store || doc.data != nil || doc.view != nil {
+		t.Fatalf("저장 방식이 바뀜: %T", doc.store)
+	}
+	if err := doc.Insert(1, 3, "!"); err != nil || doc.Text() != "one\ntwo!" {
+		t.Fatalf("편집 결과 = %q, %v", doc.Text(), err)
+	}
+}
+
+func BenchmarkDocumentBackends(b *testing.B) {
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog\n", 5000)
+	paste := strings.Repeat("pasted line\n", 100)
+	backends := []struct {
+		name string
+		open func() *Document
+	}{
+		{"lines", func() *Document { return NewDocument(text) }},
+		{"pieces", func() *Document { return NewDocumentOn(NewPieceStore(text)) }},
+	}
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			doc := backend.open()
+			mid := doc.LineCount() / 2
+			b.ResetTimer()
+			for i := range b.N {
+				doc.Insert(mid, 10, "x")
+				doc.Insert(mid, 5, "\n")
+				doc.Delete(Range{Start: Position{mid, 5}, End: Position{mid + 1, 0}})
+				if i%100 == 0 {
+					doc.Insert(mid, 0, paste)
+				}
+			}
+		})
+	}
+}
+
+func TestLargeFileMode(t *testing.T) {
+	// 색인 조각(1MB)보다 커서 나머지는 백그라운드에서 색인됨
+	var b strings.Builder
+	for i := range 60000 {
+		fmt.Fprintf(&b, "line %05d 가나다 padding\r\n", i)
+	}
+	b.WriteString("needle at the end")
+	sp := OpenLargeFile(storage.NewMemoryStorage([]byte(b.String())), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	defer sp.Close()
+	if n := sp.doc.LineCount(); n > 5*sp.visibleLineCount() {
+		t.Fatalf("처음부터 노드 %d개를 만듦", n)
+	}
+	for deadline := time.Now().Add(5 * time.Second); sp.Status().Indexing; {
+		if time.Now().After(deadline) {
+			t.Fatal("색인이 끝나지 않음")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if st := sp.Status(); st.TotalLines != 60001 || sp.eol != EOLCRLF {
+		t.Fatalf("라인 수 = %d, eol = %q", st.TotalLines, sp.eol)
+	}
+
+	// 아래로 계속 내려가면 창이 따라옴 (줄 번호는 파일 기준)
+	for range 200 {
+		sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyDown}})
+	}
+	node := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	if line := sp.Status().Line; line != 201 || node.PieceTable.String() != "line 00200 가나다 padding" {
+		t.Fatalf("200줄 아래 = 라인 %d %q", line, node.PieceTable.String())
+	}
+	if n := sp.doc.LineCount(); n > 5*sp.visibleLineCount() {
+		t.Fatalf("스크롤 후 노드 %d개", n)
+	}
+
+	sp.GotoLine(50000)
+	node = sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	if sp.Status().Line != 50000 || node.PieceTable.String() != "line 49999 가나다 padding" {
+		t.Fatalf("goto 50000 = 라인 %d %q", sp.Status().Line, node.PieceTable.String())
+	}
+
+	// 검색은 창 밖의 매치로 창을 옮기고, 끝에서 처음으로 넘어감
+	sp.StartSearch()
+	sp.UpdateSearch("needle")
+	if pos := sp.cursorFilePos(); pos != (Position{Line: 60000, Col: 0}) {
+		t.Fatalf("needle 위치 = %+v", pos)
+	}
+	sp.UpdateSearch("line 00003 가나")
+	if pos := sp.cursorFilePos(); pos.Line != 3 {
+		t.Fatalf("처음으로 넘어간 검색 = %+v", pos)
+	}
+	if _, wrapped := sp.FindNext(false); !wrapped || sp.cursorFilePos().Line != 3 {
+		t.Fatalf("이전 매치 = %+v wrapped=%v", sp.cursorFilePos(), wrapped)
+	}
+	sp.UpdateSearch("다 pad")
+	if pos := sp.cursorFilePos(); pos != (Position{Line: 49999, Col: 13}) {
+		t.Fatalf("글자 단위 칸 = %+v", pos)
+	}
+	sp.EndSearch(false)
+	if sp.Status().Line != 50000 {
+		t.Fatalf("검색 취소 후 라인 %d", sp.Status().Line)
+	}
+
+	// 편집/저장은 거부
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'x'}})
+	sp.ProcessCommand(commander.Command{Code: commander.CmdSave})
+	if sp.IsDirty() || node.PieceTable.String() != "line 49999 가나다 padding" {
+		t.Fatal("대용량 파일이 수정됨")
+	}
+}
+
+func TestLargeFileWhileIndexing(t *testing.T) {
+	// 색인 고루틴 대신 테스트가 조각을 하나씩 훑음
+	defer func(start func(*lineIndex)) { startIndexing = start }(startIndexing)
+	startIndexing = func(*lineIndex) {}
+
+	var b strings.Builder
+	for i := range 100000 {
+		fmt.Fprintf(&b, "line %06d 가나다 padding\n", i)
+	}
+	b.WriteString("needle at the end")
+	path := filepath.Join(t.TempDir(), "big.log")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	st := &countingMapper{MappedFileStorage: storage.NewMappedFileStorage(path)}
+	sp := OpenLargeFile(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	index := sp.large.index
+	status := sp.Status()
+	if !status.Indexing || status.TotalLines == 0 || status.TotalLines >= 50000 {
+		t.Fatalf("첫 조각 뒤 상태 = %+v", status)
+	}
+
+	// 색인중에도 색인된 부분은 스크롤/이동 가능하고, 그 뒤로는 못 감
+	for range 100 {
+		sp.ProcessCommand(commander.Command{Code: commander.CmdMove, Input: commander.CharInput{Char: commander.KeyDown}})
+	}
+	if pos := sp.cursorFilePos(); pos.Line != 100 || sp.CursorNode().Text() != "line 000100 가나다 padding" {
+		t.Fatalf("색인중 스크롤 = %+v %q", pos, sp.CursorNode().Text())
+	}
+	sp.GotoLine(90000)
+	if line := sp.Status().Line; line != status.TotalLines {
+		t.Fatalf("색인 밖으로 이동 = 라인 %d (색인 %d줄)", line, status.TotalLines)
+	}
+
+	// 검색은 searchLimit(끝을 아는 줄)까지만. 그 뒤의 매치는 더 훑은 다음에 찾음
+	sp.GotoLine(1)
+	sp.StartSearch()
+	sp.UpdateSearch("line 000020 가")
+	if pos := sp.cursorFilePos(); pos.Line != 20 || !sp.Status().Indexing {
+		t.Fatalf("색인중 검색 = %+v", pos)
+	}
+	limit := index.searchLimit()
+	if limit >= strings.Index(b.String(), "line 060000") {
+		t.Fatalf("첫 조각의 searchLimit = %d", limit)
+	}
+	sp.UpdateSearch("line 060000")
+	if pos := sp.cursorFilePos(); pos.Line != 0 {
+		t.Fatalf("색인 밖의 매치로 감 = %+v", pos)
+	}
+	index.scanChunk()
+	if index.searchLimit() <= limit || !sp.Status().Indexing {
+		t.Fatalf("두 번째 조각 뒤 searchLimit = %d", index.searchLimit())
+	}
+	sp.UpdateSearch("line 060000")
+	if pos := sp.cursorFilePos(); pos.Line != 60000 {
+		t.Fatalf("두 번째 조각 뒤 검색 = %+v", pos)
+	}
+	sp.UpdateSearch("needle")
+	if pos := sp.cursorFilePos(); pos.Line != 0 {
+		t.Fatalf("마지막 줄은 아직 끝을 모름 = %+v", pos)
+	}
+	for index.scanChunk() {
+	}
+	sp.UpdateSearch("needle")
+	if pos := sp.cursorFilePos(); pos.Line != 100000 || sp.Status().Indexing {
+		t.Fatalf("색인 완료 뒤 검색 = %+v", pos)
+	}
+	sp.EndSearch(false)
+
+	sp.Close()
+	sp.Close()
+	if st.unmapped != 1 {
+		t.Fatalf("닫은 뒤 해제 %d번", st.unmapped)
+	}
+}
+
+func TestLargeFileFallsBackForOtherEncodings(t *testing.T) {
+	data, err := charset.Encode(strings.Repeat("가나다 라마바\n", 1000), charset.EUCKR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "euckr.txt")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	st := &countingMapper{MappedFileStorage: storage.NewMappedFileStorage(path)}
+	sp := OpenLargeFile(st, 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	defer sp.Close()
+	if sp.LargeFile() || sp.Encoding() != charset.EUCKR || st.unmapped != 1 {
+		t.Fatalf("대용량 모드 %v, 인코딩 %s, 해제 %d번", sp.LargeFile(), sp.Encoding(), st.unmapped)
+	}
+	if line, _ := sp.Document().Line(0); line != "가나다 라마바" {
+		t.Fatalf("디코딩 결과 = %q", line)
+	}
+	if msg := sp.Status().Message; msg != "Large file is euc-kr, opened normally" {
+		t.Fatalf("메시지 = %q", msg)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"같음", []string{"a", "b"}, []string{"a", "b"}, nil},
+		{"가운데 바뀜", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []string{"@@ 2", "+ x", "- b"}},
+		{"끝에 추가", []string{"a"}, []string{"a", "b", "c"}, []string{"@@ 2", "+ b", "+ c"}},
+		{"처음 삭제", []string{"a", "b"}, []string{"b"}, []string{"@@ 1", "- a"}},
+		{"떨어진 두 곳", []string{"a", "b", "c", "d"}, []string{"x", "b", "c"}, []string{"@@ 1", "+ x", "- a", "@@ 4", "- d"}},
+		{"빈 버퍼", nil, []string{"a"}, []string{"@@ 1", "+ a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.a, tt.b); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("lineDiff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 실제 파일로 외부 변경 감지: 자기 자신의 저장은 변경이 아님
+func TestFileChangedOnDiskWithFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sp := LoadSyncProtocol(storage.NewFileStorage(path), 800, 600, 0xFF000000, 0xFFFFFFFF, 16)
+	if changed, err := sp.FileChangedOnDisk(); err != nil || changed {
+		t.Fatalf("연 직후 변경 = %v %v", changed, err)
+	}
+
+	sp.ProcessCommand(commander.Command{Code: commander.CmdInsert, Input: commander.CharInput{Char: 'X'}})
+	if err := sp.SaveToFile(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := sp.FileChangedOnDisk(); changed {
+		t.Fatal("자기 저장을 외부 변경으로 봄")
+	}
+
+	if err := os.WriteFile(path, []byte("rewritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := sp.FileChangedOnDisk(); !changed {
+		t.Fatal("외부 변경을 감지하지 못함")
+	}
+	if diff, err := sp.DiffWithFile(); err != nil || diff != "@@ 1\n+ rewritten\n- Xone\n- two" {
+		t.Fatalf("차이 = %q %v", diff, err)
+	}
+
+	// 지워진 파일은 변경으로 보지 않음 (종료시 다시 저장됨)
+	os.Remove(path)
+	if changed, err := sp.FileChangedOnDisk(); err != nil || changed {
+		t.Fatalf("지운 뒤 변경 = %v %v", changed, err)
+	}
+}
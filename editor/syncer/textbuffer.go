@@ -0,0 +1,123 @@
+package syncer
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+)
+
+// runeStep: 글자 인덱스 간격. runeStep 글자마다 바이트 위치를 하나 기억함
+const runeStep = 128
+
+// textBuffer: PieceTable의 UTF-8 바이트 버퍼
+// 조각은 글자(rune) 단위로 가리키고, 바이트 위치는 글자 인덱스(marks)로 찾음
+//   - ASCII만 있는 동안은 글자 위치 = 바이트 위치라서 인덱스를 만들지 않음
+//   - 그 외에는 runeStep 글자마다의 바이트 위치에서 최대 runeStep 글자만 건너뜀
+//
+// data는 파일의 읽기 전용 매핑일 수 있으므로 절대 고쳐 쓰지 않고 뒤에 덧붙이기만 함
+type textBuffer struct {
+	data     []byte
+	marks    []int // marks[i] = i*runeStep번째 글자의 바이트 위치 (ascii면 nil)
+	newlines []int // 줄바꿈(\n)의 글자 위치 (오름차순, 문서 전체 테이블의 줄 찾기용)
+	runes    int
+	ascii    bool
+	// live: 지금 테이블들이 가리키는 글자 수의 추정치 (붙이면 늘고 테이블에서 지우면 줆)
+	// 버퍼를 나눠 쓰는 테이블들 전체 기준이라 정리 시점은 이 값으로 정함 (compactIfSparse)
+	// 롤백 사본이나 통째로 버린 라인은 세지 않으므로 정확하지 않아도 됨
+	live int
+}
+
+// newTextBuffer: data를 복사하지 않고 감쌈
+func newTextBuffer(data []byte) *textBuffer {
+	b := &textBuffer{ascii: true}
+	b.index(data)
+	b.data = data
+	return b
+}
+
+// index: 뒤에 붙을 p의 글자 수/인덱스를 반영 (b.data에 붙이기 전에 호출)
+func (b *textBuffer) index(p []byte) {
+	base := len(b.data)
+	if b.ascii {
+		if isASCII(p) {
+			for i := bytes.IndexByte(p, '\n'); i >= 0; {
+				b.newlines = append(b.newlines, b.runes+i)
+				next := bytes.IndexByte(p[i+1:], '\n')
+				if next < 0 {
+					break
+				}
+				i += next + 1
+			}
+			b.runes += len(p)
+			return
+		}
+		// 처음 나온 비ASCII: 지금까지의 ASCII 구간 인덱스를 채움
+		b.ascii = false
+		for r := 0; r < b.runes; r += runeStep {
+			b.marks = append(b.marks, r)
+		}
+	}
+	for i := 0; i < len(p); {
+		if b.runes%runeStep == 0 {
+			b.marks = append(b.marks, base+i)
+		}
+		if p[i] == '\n' {
+			b.newlines = append(b.newlines, b.runes)
+		}
+		_, size := utf8.DecodeRune(p[i:])
+		i += size
+		b.runes++
+	}
+}
+
+// appendString: 끝에 s를 붙이고 s가 시작하는 글자 위치를 돌려줌
+func (b *textBuffer) appendString(s string) int {
+	start := b.runes
+	b.index([]byte(s))
+	b.data = append(b.data, s...)
+	b.live += b.runes - start
+	return start
+}
+
+// byteOffset: r번째 글자의 바이트 위치
+func (b *textBuffer) byteOffset(r int) int {
+	if b.ascii {
+		return r
+	}
+	if r >= b.runes {
+		return len(b.data)
+	}
+	off := b.marks[r/runeStep]
+	for range r % runeStep {
+		_, size := utf8.DecodeRune(b.data[off:])
+		off += size
+	}
+	return off
+}
+
+// bytes: [start, start+length) 글자의 바이트
+func (b *textBuffer) bytes(start, length int) []byte {
+	return b.data[b.byteOffset(start):b.byteOffset(start+length)]
+}
+
+// newlinesIn: [start, start+length) 글자 안의 줄바꿈 수
+func (b *textBuffer) newlinesIn(start, length int) int {
+	if len(b.newlines) == 0 {
+		return 0
+	}
+	return sort.SearchInts(b.newlines, start+length) - sort.SearchInts(b.newlines, start)
+}
+
+// nthNewline: start 이후 k번째(0부터) 줄바꿈의 글자 위치
+func (b *textBuffer) nthNewline(start, k int) int {
+	return b.newlines[sort.SearchInts(b.newlines, start)+k]
+}
+
+func isASCII(p []byte) bool {
+	for _, c := range p {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package syncer
+
+import "go_editor/editor/theme"
+
+// SetTheme: 테마 교체 후 모든 라인버퍼를 다시 그림
+func (sp *SyncProtocol) SetTheme(t *theme.Theme) {
+	sp.theme = t
+	sp.fgColor = uint32(t.Foreground)
+	sp.bgColor = uint32(t.Background)
+	// 커서는 rerenderAll에서 지웠다가 새 색으로 다시 그려짐
+	sp.cursor.color = uint32(t.Cursor)
+	sp.rerenderAll()
+}
+
+// Theme: 현재 테마 (SetTheme 전이면 nil)
+func (sp *SyncProtocol) Theme() *theme.Theme {
+	return sp.theme
+}
+
+// lineBackground: 노드의 배경색 (커서가 있는 라인은 현재 라인 색)
+func (sp *SyncProtocol) lineBackground(sn *SyncNode) uint32 {
+	if sp.theme != nil && sn.LineBuffer != nil && sn.LineBuffer == sp.cursor.currentLineBuffer {
+		return uint32(sp.theme.CurrentLine)
+	}
+	return sp.bgColor
+}
+
+// refreshCurrentLine: 커서 라인이 바뀌었으면 이전/현재 라인을 다시 그림
+// op 시퀀스에서 커서 이동은 싱크 이후에 일어나므로 명령 처리 끝에 한 번 호출
+func (sp *SyncProtocol) refreshCurrentLine() {
+	cur := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	prev := sp.highlightedNode
+	sp.highlightedNode = cur
+	if cur == prev || sp.theme == nil {
+		return
+	}
+
+	wasVisible := sp.cursor.visible
+	sp.cursor.ClearCursor(sp)
+	// 이전 노드는 삭제/머지로 리스트에서 빠졌을 수 있음
+	if prev != nil && prev.LineBuffer != nil && sp.doc.data.findOrder(prev) >= 0 {
+		sp.syncNode(prev)
+	}
+	if cur != nil {
+		sp.syncNode(cur)
+	}
+	if wasVisible {
+		sp.cursor.CusorDrawOn(sp)
+	}
+}
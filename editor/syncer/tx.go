@@ -0,0 +1,139 @@
+package syncer
+
+import "fmt"
+
+// opTx: ExecuteAll 한 번의 롤백 정보
+// op가 건드릴 수 있는 노드(대상 노드, 커서 노드와 그 이웃)의 링크와 PieceTable, 커서, head를 저장
+// 성공한 편집의 opTx는 되돌리기 기록으로도 쓰임 (undo.go)
+type opTx struct {
+	head        *SyncNode
+	cursorLine  *LineBuffer
+	cursorInset int
+	nodes       map[*SyncNode]nodeState
+}
+
+type nodeState struct {
+	prev, next *SyncNode
+	pieceTable *PieceTable
+}
+
+func beginTx(sp *SyncProtocol, ops *OpSequences) *opTx {
+	tx := newTx(sp)
+	ops.ForEach(func(op Op) {
+		tx.capture(op.Info().Target)
+	})
+	return tx
+}
+
+// newTx: 지금 head/커서와 커서 노드만 저장한 트랜잭션 (바뀔 노드는 capture로 추가)
+func newTx(sp *SyncProtocol) *opTx {
+	tx := &opTx{
+		head:        sp.doc.data.head,
+		cursorLine:  sp.cursor.currentLineBuffer,
+		cursorInset: sp.cursor.currentCharInset,
+		nodes:       map[*SyncNode]nodeState{},
+	}
+	tx.capture(sp.doc.data.findSyncNodeByLineBuffer(tx.cursorLine))
+	return tx
+}
+
+// capture: 노드와 앞뒤 이웃 저장 (슬라이스/머지/삭제는 이웃의 링크도 바꿈)
+func (tx *opTx) capture(n *SyncNode) {
+	if n == nil {
+		return
+	}
+	for _, node := range []*SyncNode{n.prev, n, n.next} {
+		if node == nil {
+			continue
+		}
+		if _, ok := tx.nodes[node]; ok {
+			continue
+		}
+		state := nodeState{prev: node.prev, next: node.next}
+		if node.PieceTable != nil {
+			state.pieceTable = node.PieceTable.clone()
+		}
+		tx.nodes[node] = state
+	}
+}
+
+// check: 건드린 노드 주변 링크와 커서 검사 (문서 전체를 돌지 않음)
+func (tx *opTx) check(sp *SyncProtocol) []string {
+	var violations []string
+	head := sp.doc.data.head
+	if head == nil {
+		return []string{"리스트가 비어 있음"}
+	}
+	if head.prev != nil {
+		violations = append(violations, "head.prev가 nil이 아님")
+	}
+	for n := range tx.nodes {
+		if n.prev == nil && n.next == nil && n != head {
+			// 삭제/머지로 빠진 노드
+			continue
+		}
+		if n.next != nil && n.next.prev != n {
+			violations = append(violations, "노드의 next.prev가 자신이 아님")
+		}
+		if n.prev != nil && n.prev.next != n {
+			violations = append(violations, "노드의 prev.next가 자신이 아님")
+		}
+	}
+	node := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	if node == nil {
+		return append(violations, "커서의 라인이 리스트에 없음")
+	}
+	if inset := sp.cursor.currentCharInset; inset < 0 || inset > node.PieceTable.Length() {
+		violations = append(violations, fmt.Sprintf("커서 인셋 %d가 라인 길이 %d 밖", inset, node.PieceTable.Length()))
+	}
+	return violations
+}
+
+// rollback: 저장한 상태로 되돌리고 해당 라인들 다시 그림
+// 실행 중 새로 만들어진 노드는 링크가 복원되면서 리스트에서 빠짐
+func (tx *opTx) rollback(sp *SyncProtocol) {
+	tx.restore(sp)
+	for n := range tx.nodes {
+		sp.syncNode(n)
+	}
+}
+
+// restore: head, 저장한 노드들의 링크/PieceTable, 커서를 되돌림 (다시 그리지는 않음)
+func (tx *opTx) restore(sp *SyncProtocol) {
+	sp.doc.data.head = tx.head
+	for n, state := range tx.nodes {
+		n.prev, n.next = state.prev, state.next
+		if state.pieceTable != nil {
+			n.PieceTable = state.pieceTable
+		}
+	}
+	sp.cursor.currentLineBuffer = tx.cursorLine
+	sp.cursor.currentCharInset = tx.cursorInset
+}
+
+// current: 같은 노드들의 지금 상태 (되돌리기를 다시 실행하기 위한 반대 방향 기록)
+func (tx *opTx) current(sp *SyncProtocol) *opTx {
+	cur := &opTx{
+		head:        sp.doc.data.head,
+		cursorLine:  sp.cursor.currentLineBuffer,
+		cursorInset: sp.cursor.currentCharInset,
+		nodes:       make(map[*SyncNode]nodeState, len(tx.nodes)),
+	}
+	for n := range tx.nodes {
+		state := nodeState{prev: n.prev, next: n.next}
+		if n.PieceTable != nil {
+			state.pieceTable = n.PieceTable.clone()
+		}
+		cur.nodes[n] = state
+	}
+	return cur
+}
+
+// merge: later의 노드 중 처음 보는 것만 추가 (먼저 저장한 상태가 묶음 시작 시점의 상태)
+func (tx *opTx) merge(later *opTx) {
+	for n, state := range later.nodes {
+		if _, ok := tx.nodes[n]; !ok {
+			tx.nodes[n] = state
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package syncer
+
+import "go_editor/editor/commander"
+
+// 되돌리기 기록 최대 개수
+const maxUndo = 200
+
+// 되돌리기 기록 하나는 묶음이 시작되기 전의 head/커서와, 묶음 동안 바뀐 노드들의 처음 상태 (opTx)
+// PieceTable은 복사해도 트리를 공유하므로 문서 전체를 복사하지 않고 바뀐 노드만큼만 듦
+
+// pushUndo: 바뀌기 직전 상태를 기록. 새 변경이 생기면 다시 실행 기록은 버림
+func (sp *SyncProtocol) pushUndo(tx *opTx) {
+	sp.undoStack = append(sp.undoStack, tx)
+	if len(sp.undoStack) > maxUndo {
+		sp.undoStack = sp.undoStack[1:]
+	}
+	sp.redoStack = nil
+}
+
+// recordEdit: 편집 명령이 성공한 뒤 그 트랜잭션으로 호출
+// 같은 종류의 연속 입력(글자 입력, 백스페이스)은 한 번에 되돌리고, 줄바꿈이나 이동이 끼면 새로 묶음
+func (sp *SyncProtocol) recordEdit(cmd commander.Command, tx *opTx) {
+	newline := false
+	if ch, ok := cmd.Input.(commander.CharInput); ok {
+		newline = ch.Char == commander.KeyEnter1 || ch.Char == commander.KeyEnter2
+	}
+	if !sp.undoGroupOpen || cmd.Code != sp.lastEditCode || newline || len(sp.undoStack) == 0 {
+		sp.pushUndo(tx)
+	} else {
+		sp.undoStack[len(sp.undoStack)-1].merge(tx)
+	}
+	sp.undoGroupOpen = !newline
+	sp.lastEditCode = cmd.Code
+}
+
+// clearUndo: 문서를 통째로 바꿨을 때 (다시 불러오기) 기록을 버림
+func (sp *SyncProtocol) clearUndo() {
+	sp.undoStack, sp.redoStack = nil, nil
+	sp.breakUndoGroup()
+}
+
+// breakUndoGroup: 편집이 아닌 명령 (이동 등) 뒤의 입력은 새로 묶음
+func (sp *SyncProtocol) breakUndoGroup() {
+	sp.undoGroupOpen = false
+}
+
+// Undo: 마지막 변경 묶음을 되돌림. 되돌릴 게 없으면 false
+func (sp *SyncProtocol) Undo() bool {
+	return sp.restoreFrom(&sp.undoStack, &sp.redoStack)
+}
+
+// Redo: 되돌린 변경을 다시 실행
+func (sp *SyncProtocol) Redo() bool {
+	return sp.restoreFrom(&sp.redoStack, &sp.undoStack)
+}
+
+// restoreFrom: from의 마지막 기록을 적용하고, 같은 노드들의 지금 상태를 to에 넣음
+func (sp *SyncProtocol) restoreFrom(from, to *[]*opTx) bool {
+	if len(*from) == 0 {
+		return false
+	}
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, entry.current(sp))
+
+	ev := ChangeEvent{Kind: DocumentReplaced}
+	if sp.doc.observed() {
+		ev.Before = sp.doc.Lines()
+	}
+	sp.withCursorHidden(func() {
+		sp.mark = nil
+		entry.restore(sp)
+		for n := range entry.nodes {
+			sp.syncNode(n)
+		}
+		sp.refreshSelection()
+	})
+	if sp.doc.observed() {
+		ev.After = sp.doc.Lines()
+	}
+	sp.EmitChange(ev)
+	sp.doc.publishChanges()
+
+	sp.refreshCurrentLine()
+	sp.updateGutter()
+	sp.refreshSearch()
+	sp.ensureCursorVisible()
+	sp.breakUndoGroup()
+	return true
+}
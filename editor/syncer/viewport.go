@@ -0,0 +1,35 @@
+package syncer
+
+// visibleLineCount: 화면에 들어가는 라인 수
+func (sp *SyncProtocol) visibleLineCount() int {
+	return max(sp.screenHeight/sp.LineHeight, 1)
+}
+
+// cursorLineIndex: 커서가 있는 노드의 0-based 순번
+func (sp *SyncProtocol) cursorLineIndex() int {
+	return sp.doc.data.findOrder(sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer))
+}
+
+// ensureCursorVisible: 커서 라인이 화면 밖이면 뷰포트를 최소한으로 옮김
+func (sp *SyncProtocol) ensureCursorVisible() {
+	line := sp.cursorLineIndex()
+	if line < 0 {
+		return
+	}
+	visible := sp.visibleLineCount()
+	if line < sp.viewTop {
+		sp.viewTop = line
+	} else if line >= sp.viewTop+visible {
+		sp.viewTop = line - visible + 1
+	}
+}
+
+// ViewTop: 화면 맨 위 라인의 0-based 순번
+func (sp *SyncProtocol) ViewTop() int {
+	return sp.viewTop
+}
+
+// LineCount: 문서 전체 라인 수
+func (sp *SyncProtocol) LineCount() int {
+	return sp.doc.LineCount()
+}
@@ -0,0 +1,72 @@
+package syncer
+
+import glp "go_editor/editor/screener/glyph"
+
+// 확대 배율 범위 (글리프는 정수배로만 늘림)
+const (
+	MinScale = 1
+	MaxScale = 4
+)
+
+// glyphWidth: 배율이 적용된 글자 폭
+func (sp *SyncProtocol) glyphWidth() int {
+	return glp.GlyphWidth * sp.scale
+}
+
+// glyphHeight: 배율이 적용된 글자 높이
+func (sp *SyncProtocol) glyphHeight() int {
+	return glp.GlyphHeight * sp.scale
+}
+
+// Scale: 현재 확대 배율
+func (sp *SyncProtocol) Scale() int {
+	return sp.scale
+}
+
+// SetScale: 배율을 바꾸고 라인 높이, 라인버퍼, 커서를 다시 만든다.
+// 커서가 있는 라인은 화면 안에 남도록 뷰포트를 옮김
+func (sp *SyncProtocol) SetScale(scale int) {
+	scale = min(max(scale, MinScale), MaxScale)
+	if scale == sp.scale {
+		return
+	}
+
+	// 이전 라인버퍼 기준 커서 노드를 먼저 찾아둠
+	cursorNode := sp.doc.data.findSyncNodeByLineBuffer(sp.cursor.currentLineBuffer)
+	wasVisible := sp.cursor.visible
+	sp.cursor.visible = false
+	sp.cursor.capturedBuffer = nil
+
+	sp.scale = scale
+	sp.LineHeight = sp.baseLineHeight * scale
+	sp.gutterWidth = sp.gutterWidthFor(sp.LineCount())
+
+	// 라인버퍼 크기가 바뀌므로 전부 새로 할당
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		sn.LineBuffer = sp.NewLineBuffer()
+	})
+	if cursorNode != nil {
+		sp.cursor.currentLineBuffer = cursorNode.LineBuffer
+	}
+	sp.resizeCursor()
+	sp.doc.data.ForEach(func(sn *SyncNode) {
+		sp.syncNode(sn)
+	})
+	sp.ensureCursorVisible()
+	if wasVisible {
+		sp.CursorDrawOn()
+	}
+}
+
+// resizeCursor: 모양과 배율에 맞게 커서 크기 결정
+func (sp *SyncProtocol) resizeCursor() {
+	c := sp.cursor
+	switch c.shape {
+	case CursorBlock:
+		c.width, c.height = sp.glyphWidth(), sp.glyphHeight()
+	case CursorUnderline:
+		c.width, c.height = sp.glyphWidth(), 2*sp.scale
+	default:
+		c.width, c.height = 2*sp.scale, sp.glyphHeight()
+	}
+}
@@ -0,0 +1,188 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_editor/editor/config"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Color: 설정 파일과 같은 "#RRGGBB" 형식
+type Color = config.Color
+
+// Theme: 화면 요소별 색 모음
+type Theme struct {
+	Name        string `json:"name"`
+	Foreground  Color  `json:"foreground"`
+	Background  Color  `json:"background"`
+	Cursor      Color  `json:"cursor"`
+	Selection   Color  `json:"selection"`
+	CurrentLine Color  `json:"current_line"`
+	Gutter      Pair   `json:"gutter"`
+	StatusBar   Pair   `json:"status_bar"`
+	// 문법 토큰 종류별 글자색 (keyword, string, comment, number, type ...)
+	Syntax map[string]Color `json:"syntax"`
+}
+
+// Pair: 글자색/배경색 한 쌍
+type Pair struct {
+	Foreground Color `json:"foreground"`
+	Background Color `json:"background"`
+}
+
+// 문법 토큰 종류
+const (
+	TokenKeyword = "keyword"
+	TokenString  = "string"
+	TokenComment = "comment"
+	TokenNumber  = "number"
+	TokenType    = "type"
+)
+
+// 기본 제공 테마
+var (
+	Light = &Theme{
+		Name:        "light",
+		Foreground:  0xFF000000,
+		Background:  0xFFFFFFFF,
+		Cursor:      0xFF000000,
+		Selection:   0xFFB4D5FE,
+		CurrentLine: 0xFFF2F2F2,
+		Gutter:      Pair{Foreground: 0xFF999999, Background: 0xFFF0F0F0},
+		StatusBar:   Pair{Foreground: 0xFFFFFFFF, Background: 0xFF404040},
+		Syntax: map[string]Color{
+			TokenKeyword: 0xFF0000C0,
+			TokenString:  0xFFA31515,
+			TokenComment: 0xFF008000,
+			TokenNumber:  0xFF098658,
+			TokenType:    0xFF267F99,
+		},
+	}
+	Dark = &Theme{
+		Name:        "dark",
+		Foreground:  0xFFD4D4D4,
+		Background:  0xFF1E1E1E,
+		Cursor:      0xFFAEAFAD,
+		Selection:   0xFF264F78,
+		CurrentLine: 0xFF2A2A2A,
+		Gutter:      Pair{Foreground: 0xFF858585, Background: 0xFF1E1E1E},
+		StatusBar:   Pair{Foreground: 0xFFFFFFFF, Background: 0xFF007ACC},
+		Syntax: map[string]Color{
+			TokenKeyword: 0xFF569CD6,
+			TokenString:  0xFFCE9178,
+			TokenComment: 0xFF6A9955,
+			TokenNumber:  0xFFB5CEA8,
+			TokenType:    0xFF4EC9B0,
+		},
+	}
+)
+
+// Registry: 이름 -> 테마 (기본 테마 + 사용자 테마)
+type Registry struct {
+	themes map[string]*Theme
+}
+
+// NewRegistry: 기본 테마만 있는 레지스트리
+func NewRegistry() *Registry {
+	return &Registry{themes: map[string]*Theme{
+		Light.Name: Light,
+		Dark.Name:  Dark,
+	}}
+}
+
+// UserDir: 사용자 테마 디렉토리 (설정 디렉토리 아래 themes/)
+func UserDir() string {
+	return filepath.Join(filepath.Dir(config.GlobalPath()), "themes")
+}
+
+// LoadDir: dir의 *.json 테마를 읽어서 추가
+// 일부 파일이 잘못되어도 나머지는 읽고, 오류는 모아서 돌려줌
+func (r *Registry) LoadDir(dir string) []error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, path := range paths {
+		t, err := LoadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.themes[t.Name] = t
+	}
+	return errs
+}
+
+// LoadFile: 테마 파일 하나 읽기
+// "base"를 지정하면 그 테마를 복사한 위에 덮어씀 (지정 안 하면 light)
+func LoadFile(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	base := Light
+	if header.Base == Dark.Name {
+		base = Dark
+	}
+	t := base.Clone()
+	t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var body struct {
+		*Theme
+		Base string `json:"base"`
+	}
+	body.Theme = t
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if t.Name == "" {
+		return nil, fmt.Errorf("%s: 테마 이름이 비어 있습니다", path)
+	}
+	return t, nil
+}
+
+// Get: 이름으로 테마 찾기
+func (r *Registry) Get(name string) (*Theme, bool) {
+	t, ok := r.themes[name]
+	return t, ok
+}
+
+// Names: 정렬된 테마 이름 목록
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.themes))
+	for name := range r.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Next: 이름 순서상 다음 테마 (순환)
+func (r *Registry) Next(name string) *Theme {
+	names := r.Names()
+	for i, n := range names {
+		if n == name {
+			return r.themes[names[(i+1)%len(names)]]
+		}
+	}
+	return r.themes[names[0]]
+}
+
+// Clone: 맵까지 복사한 사본
+func (t *Theme) Clone() *Theme {
+	c := *t
+	c.Syntax = make(map[string]Color, len(t.Syntax))
+	for k, v := range t.Syntax {
+		c.Syntax[k] = v
+	}
+	return &c
+}
@@ -0,0 +1,71 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	r := NewRegistry()
+	for _, want := range []*Theme{Light, Dark} {
+		got, ok := r.Get(want.Name)
+		if !ok || got != want {
+			t.Fatalf("기본 테마 %q가 없음", want.Name)
+		}
+		if got.Foreground == got.Background {
+			t.Errorf("%s: 글자색과 배경색이 같음", got.Name)
+		}
+		for _, token := range []string{TokenKeyword, TokenString, TokenComment, TokenNumber, TokenType} {
+			if _, ok := got.Syntax[token]; !ok {
+				t.Errorf("%s: %s 토큰 색이 없음", got.Name, token)
+			}
+		}
+	}
+	if _, ok := r.Get("solarized"); ok {
+		t.Fatal("없는 테마를 찾음")
+	}
+	if next := r.Next("dark"); next != Light {
+		t.Fatalf("dark 다음 = %q", next.Name)
+	}
+	if next := r.Next("unknown"); next != Dark {
+		t.Fatalf("모르는 이름의 다음 = %q (정렬된 첫 테마여야 함)", next.Name)
+	}
+}
+
+func TestLoadDirOverridesBase(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("night.json", `{"base": "dark", "cursor": "#FF0000", "syntax": {"keyword": "#00FF00"}}`)
+	write("paper.json", `{"background": "#FAFAF0"}`)
+	write("broken.json", `{"foreground": `)
+
+	r := NewRegistry()
+	if errs := r.LoadDir(dir); len(errs) != 1 {
+		t.Fatalf("오류 = %v, 잘못된 파일 하나만 실패해야 함", errs)
+	}
+	if got := r.Names(); len(got) != 4 || got[0] != "dark" || got[3] != "paper" {
+		t.Fatalf("테마 목록 = %v", got)
+	}
+
+	night, _ := r.Get("night")
+	if night.Cursor != 0xFFFF0000 || night.Syntax[TokenKeyword] != 0xFF00FF00 {
+		t.Fatalf("덮어쓴 색 = %#x, %#x", night.Cursor, night.Syntax[TokenKeyword])
+	}
+	// 지정하지 않은 색은 base를 따름
+	if night.Background != Dark.Background || night.Syntax[TokenString] != Dark.Syntax[TokenString] {
+		t.Fatal("base(dark)의 색을 이어받지 않음")
+	}
+	paper, _ := r.Get("paper")
+	if paper.Background != 0xFFFAFAF0 || paper.Foreground != Light.Foreground {
+		t.Fatal("base 없는 테마는 light 위에 덮어써야 함")
+	}
+	// 사용자 테마를 고쳐도 기본 테마는 그대로
+	if Dark.Cursor == night.Cursor || Dark.Syntax[TokenKeyword] == 0xFF00FF00 {
+		t.Fatal("기본 테마가 바뀜")
+	}
+}
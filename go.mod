@@ -7,3 +7,5 @@ require (
 	github.com/BurntSushi/xgbutil v0.0.0-20190907113008-ad855c713046
 	github.com/joho/godotenv v1.5.1
 )
+
+require golang.org/x/text v0.21.0
@@ -1,13 +1,68 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"go_editor/editor"
+	"go_editor/editor/config"
+	"go_editor/editor/handlefile"
+	"go_editor/editor/logging"
+	"go_editor/editor/storage"
+	"go_editor/editor/syncer"
+	"io"
+	"log"
+	"log/slog"
+	"os"
 )
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "사용법: %s [파일]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	debugOps := flag.Bool("debug-ops", false, "op 실행 후마다 문서/커서 불변식 검사 (위반은 -log-file 로그로)")
+	traceOps := flag.String("trace-ops", "", "실행하는 op 프로그램을 이 파일에 한 줄씩 기록")
+	logFile := flag.String("log-file", "", "로그를 기록할 파일 (-이면 표준 에러, 비우면 기록 안 함)")
+	logLevel := flag.String("log-level", "info", "로그 레벨: debug, info, warn, error")
+	mapFiles := flag.Bool("mmap", false, "파일을 복사하지 않고 메모리 매핑으로 읽음 (큰 로그용, 다른 프로그램이 파일을 제자리에서 고치면 안 됨)")
+	flag.Parse()
+
+	logger, closeLog, err := openLogger(*logFile, *logLevel)
+	if err != nil {
+		log.Fatalf("로그를 설정할 수 없습니다: %v", err)
+	}
+	defer closeLog()
+	handlefile.SetLogger(logger)
+	syncer.SetDebugChecks(*debugOps)
+	storage.SetMapFiles(*mapFiles)
+	if *traceOps != "" {
+		f, err := os.Create(*traceOps)
+		if err != nil {
+			log.Fatalf("op 기록 파일을 만들 수 없습니다: %v", err)
+		}
+		defer f.Close()
+		syncer.SetOpTrace(f)
+	}
+
+	// 환경변수 (설정 디렉토리의 .env)
+	handlefile.LoadEnv()
+
+	// 열 파일: 인자 > SAVE_TXT > XDG 데이터 디렉토리
+	savePath, err := handlefile.ResolveOpenPath(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("열 파일 경로를 정할 수 없습니다: %v", err)
+	}
 
-	// Editor 생성 (800x600, 30FPS)
-	edt, err := editor.NewEditor(800, 600, 30)
+	// 설정 로드 (전역 config.json + 열린 파일 기준 프로젝트 설정)
+	sources := config.Locate(savePath)
+	cfg, err := config.Load(sources)
+	if err != nil {
+		logger.Warn("설정 오류로 기본 설정 사용", "err", err)
+		cfg = config.Default()
+	}
+
+	// Editor 생성 (설정의 창 크기, FPS)
+	edt, err := editor.NewEditor(savePath, cfg, sources, logger)
 	if err != nil {
 		panic(err)
 	}
@@ -17,3 +72,27 @@ func main() {
 
 	// 종료 후 정리
 }
+
+// openLogger: -log-file, -log-level로 로거 생성. 파일이 없으면 출력 없는 로거
+func openLogger(path, levelName string) (*slog.Logger, func(), error) {
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var w io.Writer
+	closeFn := func() {}
+	switch path {
+	case "":
+		return logging.Discard(), closeFn, nil
+	case "-":
+		w = os.Stderr
+	default:
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = f
+		closeFn = func() { f.Close() }
+	}
+	return logging.New(w, level), closeFn, nil
+}